@@ -0,0 +1,38 @@
+package fastcdc
+
+// WithAlignment rounds each cut point down to a multiple of n bytes from
+// the start of the stream, or up if that would make the chunk empty. This
+// keeps boundaries on page, cluster or block boundaries of structured files,
+// while content-defined chunking still picks which ones to use. Alignment
+// should be well below the minimum size for the chunk sizes to hold. An n
+// of zero or less disables alignment.
+func WithAlignment(n int) Option {
+	return func(c *Chunker) {
+		if n > 0 {
+			c.policies = append(c.policies, alignPolicy(n))
+		}
+	}
+}
+
+type alignPolicy int
+
+func (n alignPolicy) Hint(offset int, data []byte) int {
+	return 0
+}
+
+// Adjust returns cutPoint moved to an aligned stream offset
+func (n alignPolicy) Adjust(offset int, data []byte, cutPoint int) int {
+	align := int(n)
+	end := offset + cutPoint
+	if end%align == 0 {
+		return cutPoint
+	}
+
+	if down := end - end%align; down > offset {
+		return down - offset
+	}
+	if up := end - end%align + align - offset; up <= len(data) {
+		return up
+	}
+	return cutPoint
+}
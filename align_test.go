@@ -0,0 +1,29 @@
+package fastcdc
+
+import "testing"
+
+func TestAlignment(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 3)
+
+	chunks := chunkAll(t, data, WithAlignment(512))
+	for _, c := range chunks[:len(chunks)-1] {
+		if (c.Offset+c.Length)%512 != 0 {
+			t.Fatalf("expected cut points aligned to 512, got %d", c.Offset+c.Length)
+		}
+	}
+
+	// Zero disables alignment instead of dividing by it
+	plain := chunkAll(t, data)
+	for _, n := range []int{0, -1} {
+		chunks := chunkAll(t, data, WithAlignment(n))
+		if len(chunks) != len(plain) {
+			t.Fatalf("alignment %d: expected %d chunks, got %d", n, len(plain), len(chunks))
+		}
+		for i := range chunks {
+			if chunks[i].Length != plain[i].Length {
+				t.Fatalf("alignment %d: chunk %d differs", n, i)
+			}
+		}
+	}
+}
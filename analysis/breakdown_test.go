@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestBreakdown(t *testing.T) {
+	b := NewBreakdown(2)
+	image := datagen.LCG(5000, 1)
+	for i, path := range []string{"img/a/x.PNG", "img/a/y.png", "img/b/z.png", "doc/readme"} {
+		b.Add(path, image)
+		b.Add(path, datagen.LCG(1000, uint32(i+2)))
+	}
+
+	exts := b.ByExtension()
+	if len(exts) != 2 || exts[0].Name != ".png" || exts[1].Name != "" {
+		t.Fatalf("expected .png and no extension, got %+v", exts)
+	}
+	if png := exts[0]; png.Files != 3 || png.Chunks != 6 || png.Bytes != 18000 || png.DupBytes != 10000 || png.DupChunks != 2 {
+		t.Fatalf("unexpected .png group %+v", png)
+	}
+	if got := exts[0].DedupRatio(); got != 18000.0/8000 {
+		t.Fatalf("expected dedup ratio %.2f, got %.2f", 18000.0/8000, got)
+	}
+
+	var dirs []string
+	for _, g := range b.ByDirectory() {
+		dirs = append(dirs, g.Name)
+	}
+	if want := []string{"img", "doc", "img/a", "img/b"}; !slices.Equal(dirs, want) {
+		t.Fatalf("expected directories %v, got %v", want, dirs)
+	}
+}
+
+func TestDirPrefixes(t *testing.T) {
+	for path, want := range map[string][]string{
+		"file":       {"."},
+		"/file":      {"/"},
+		"a/b/c/file": {"a", "a/b"},
+		"/a/b/file":  {"/a", "/a/b"},
+		"a/file":     {"a"},
+	} {
+		if got := dirPrefixes(path, 2); !slices.Equal(got, want) {
+			t.Errorf("%s: expected %v, got %v", path, want, got)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package analysis
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestEstimator(t *testing.T) {
+	e := NewEstimator(DefaultPrecision)
+
+	// 50000 distinct chunks, each added twice
+	const unique = 50000
+	var chunk [16]byte
+	for round := 0; round < 2; round++ {
+		for i := 0; i < unique; i++ {
+			binary.LittleEndian.PutUint64(chunk[:], uint64(i))
+			e.Add(chunk[:])
+		}
+	}
+
+	if e.Chunks() != 2*unique {
+		t.Fatalf("expected %d chunks, got %d", 2*unique, e.Chunks())
+	}
+
+	// Allow 4 standard errors
+	if got := e.UniqueChunks(); math.Abs(got-unique)/unique > 0.065 {
+		t.Errorf("expected about %d unique chunks, got %.0f", unique, got)
+	}
+	if got := e.DedupRatio(); math.Abs(got-2) > 0.15 {
+		t.Errorf("expected dedup ratio about 2, got %.2f", got)
+	}
+}
+
+func TestEstimatorSmall(t *testing.T) {
+	e := NewEstimator(DefaultPrecision)
+	for i := 0; i < 100; i++ {
+		e.Add([]byte{byte(i)})
+	}
+	if got := e.UniqueChunks(); math.Abs(got-100) > 2 {
+		t.Errorf("expected about 100 unique chunks, got %.1f", got)
+	}
+}
+
+func TestEstimatorMerge(t *testing.T) {
+	a := NewEstimator(DefaultPrecision)
+	b := NewEstimator(DefaultPrecision)
+	all := NewEstimator(DefaultPrecision)
+
+	var chunk [8]byte
+	for i := 0; i < 20000; i++ {
+		binary.LittleEndian.PutUint64(chunk[:], uint64(i))
+		if i%2 == 0 {
+			a.Add(chunk[:])
+		} else {
+			b.Add(chunk[:])
+		}
+		all.Add(chunk[:])
+	}
+
+	a.Merge(b)
+	if a.Chunks() != all.Chunks() || a.UniqueChunks() != all.UniqueChunks() {
+		t.Errorf("merged sketch differs: %d/%.0f vs %d/%.0f",
+			a.Chunks(), a.UniqueChunks(), all.Chunks(), all.UniqueChunks())
+	}
+}
@@ -0,0 +1,110 @@
+package analysis
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/bits"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+// Bytes of pseudorandom data chunked to measure the realized average size
+const gearSampleSize = 16 << 20
+
+// GearReport holds statistics on the quality of a gear table
+type GearReport struct {
+	BitBalance [64]float64 // Fraction of entries with each bit set
+	Duplicates int         // Entries equal to an earlier entry
+
+	// Entries colliding with an earlier entry in the masked bits. Too many
+	// collisions mean that distinct bytes affect cut points the same way.
+	CollisionsS, CollisionsL                 int
+	ExpectedCollisionsS, ExpectedCollisionsL float64
+
+	AvgChunkSize    float64 // Realized average on pseudorandom data
+	RefAvgChunkSize float64 // The same for the built-in table G
+}
+
+// CheckGear evaluates table with params p
+func CheckGear(table *[256]uint64, p fastcdc.Params) GearReport {
+	var r GearReport
+	seen := make(map[uint64]bool)
+	for _, v := range table {
+		if seen[v] {
+			r.Duplicates++
+		}
+		seen[v] = true
+		for b := range r.BitBalance {
+			r.BitBalance[b] += float64(v>>b&1) / 256
+		}
+	}
+
+	maskS, maskL := fastcdc.Masks(p.AvgSize)
+	r.CollisionsS, r.ExpectedCollisionsS = collisions(table, maskS)
+	r.CollisionsL, r.ExpectedCollisionsL = collisions(table, maskL)
+
+	data := datagen.LCG(gearSampleSize, 1)
+	r.AvgChunkSize = avgChunkSize(data, p, table)
+	r.RefAvgChunkSize = avgChunkSize(data, p, &fastcdc.G)
+	return r
+}
+
+// Warnings returns problems found in the report, none for a sound table
+func (r GearReport) Warnings() []string {
+	var warnings []string
+
+	// The number of set bits is binomial with a standard deviation of
+	// 8 for 256 entries, so allow 4 of them
+	for b, balance := range r.BitBalance {
+		if math.Abs(balance-0.5)*256 > 32 {
+			warnings = append(warnings, fmt.Sprintf("bit %d is set in %.1f%% of entries", b, 100*balance))
+		}
+	}
+	if r.Duplicates > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d duplicate entries", r.Duplicates))
+	}
+	if float64(r.CollisionsS) > 2*r.ExpectedCollisionsS+4 {
+		warnings = append(warnings, fmt.Sprintf("%d collisions under the small mask, expected %.1f", r.CollisionsS, r.ExpectedCollisionsS))
+	}
+	if float64(r.CollisionsL) > 2*r.ExpectedCollisionsL+4 {
+		warnings = append(warnings, fmt.Sprintf("%d collisions under the large mask, expected %.1f", r.CollisionsL, r.ExpectedCollisionsL))
+	}
+	if math.Abs(r.AvgChunkSize-r.RefAvgChunkSize) > 0.1*r.RefAvgChunkSize {
+		warnings = append(warnings, fmt.Sprintf("average chunk size %.0f, built-in table gives %.0f", r.AvgChunkSize, r.RefAvgChunkSize))
+	}
+	return warnings
+}
+
+// collisions counts entries whose masked bits equal those of an earlier
+// entry, along with the count expected from a random table
+func collisions(table *[256]uint64, mask uint64) (int, float64) {
+	seen := make(map[uint64]bool)
+	n := 0
+	for _, v := range table {
+		if seen[v&mask] {
+			n++
+		}
+		seen[v&mask] = true
+	}
+
+	values := math.Ldexp(1, bits.OnesCount64(mask))
+	expected := 256 - values*(1-math.Pow(1-1/values, 256))
+	return n, expected
+}
+
+func avgChunkSize(data []byte, p fastcdc.Params, table *[256]uint64) float64 {
+	chunker := fastcdc.NewChunkerWithParams(bytes.NewReader(data), p.MinSize, p.AvgSize, p.MaxSize,
+		fastcdc.WithGearTable(table))
+
+	chunks := 0
+	for {
+		// Reading from memory cannot fail
+		if _, err := chunker.Next(); err != nil {
+			break
+		}
+		chunks++
+	}
+	return float64(len(data)) / float64(chunks)
+}
@@ -0,0 +1,30 @@
+package analysis
+
+import (
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+func TestCheckGear(t *testing.T) {
+	r := CheckGear(&fastcdc.G, fastcdc.DefaultParams)
+	if w := r.Warnings(); len(w) > 0 {
+		t.Errorf("expected no warnings for built-in table, got %v", w)
+	}
+	if r.AvgChunkSize != r.RefAvgChunkSize {
+		t.Errorf("expected same average for built-in table, got %.0f and %.0f", r.AvgChunkSize, r.RefAvgChunkSize)
+	}
+
+	if w := CheckGear(fastcdc.GearTable(1), fastcdc.DefaultParams).Warnings(); len(w) > 0 {
+		t.Errorf("expected no warnings for seeded table, got %v", w)
+	}
+
+	// Clearing the low bits breaks balance and the masks
+	var bad [256]uint64
+	for i, v := range fastcdc.G {
+		bad[i] = v &^ 0xFFFF
+	}
+	if w := CheckGear(&bad, fastcdc.DefaultParams).Warnings(); len(w) == 0 {
+		t.Errorf("expected warnings for a biased table")
+	}
+}
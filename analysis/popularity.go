@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"slices"
+	"sync"
+)
+
+// PopularChunk is a chunk with the number of times it occurs
+type PopularChunk struct {
+	Hash   [sha256.Size]byte
+	Length int
+	Zero   bool     // Whether the chunk is all zeros
+	Refs   int64    // Occurrences across all files
+	Files  []string // Files it occurs in, up to the limit of the Popularity
+}
+
+// Saved returns the bytes saved by storing the chunk once
+func (c PopularChunk) Saved() int64 {
+	return (c.Refs - 1) * int64(c.Length)
+}
+
+// Popularity counts the occurrences of each chunk, to find the most
+// referenced ones. These point out pathological dedup, such as runs of
+// zeros or headers common to many files, and are the candidates for
+// caching. Unlike Estimator it keeps every chunk hash in memory.
+type Popularity struct {
+	mu       sync.Mutex
+	maxFiles int
+	chunks   map[[sha256.Size]byte]*PopularChunk
+}
+
+// NewPopularity returns a Popularity recording up to maxFiles files for
+// each chunk
+func NewPopularity(maxFiles int) *Popularity {
+	return &Popularity{
+		maxFiles: maxFiles,
+		chunks:   make(map[[sha256.Size]byte]*PopularChunk),
+	}
+}
+
+// Add records a chunk of the file at path. It is safe for concurrent use,
+// such as from the callback of fastcdc.ChunkFiles.
+func (p *Popularity) Add(path string, data []byte) {
+	sum := sha256.Sum256(data)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c, ok := p.chunks[sum]
+	if !ok {
+		c = &PopularChunk{Hash: sum, Length: len(data), Zero: isZero(data)}
+		p.chunks[sum] = c
+	}
+	c.Refs++
+	if len(c.Files) < p.maxFiles && !slices.Contains(c.Files, path) {
+		c.Files = append(c.Files, path)
+	}
+}
+
+// Top returns the n chunks occurring more than once that save the most
+// bytes, most first
+func (p *Popularity) Top(n int) []PopularChunk {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var top []PopularChunk
+	for _, c := range p.chunks {
+		if c.Refs > 1 {
+			top = append(top, *c)
+		}
+	}
+	slices.SortFunc(top, func(a, b PopularChunk) int {
+		return cmp.Or(
+			cmp.Compare(b.Saved(), a.Saved()),
+			cmp.Compare(b.Refs, a.Refs),
+			bytes.Compare(a.Hash[:], b.Hash[:]),
+		)
+	})
+	return top[:min(n, len(top))]
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
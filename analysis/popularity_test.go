@@ -0,0 +1,36 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestPopularity(t *testing.T) {
+	p := NewPopularity(2)
+	header := datagen.LCG(1000, 1)
+	zeros := make([]byte, 4000)
+	for _, path := range []string{"a", "b", "c"} {
+		p.Add(path, header)
+		p.Add(path, zeros)
+		p.Add(path, zeros)
+		p.Add(path, []byte(path))
+	}
+
+	top := p.Top(10)
+	if len(top) != 2 {
+		t.Fatalf("expected 2 repeated chunks, got %d", len(top))
+	}
+	if !top[0].Zero || top[0].Refs != 6 || top[0].Saved() != 5*4000 {
+		t.Fatalf("expected the zero chunk first with 6 refs, got %+v", top[0])
+	}
+	if top[1].Zero || top[1].Refs != 3 || top[1].Length != 1000 {
+		t.Fatalf("expected the header second with 3 refs, got %+v", top[1])
+	}
+	if len(top[1].Files) != 2 || top[1].Files[0] != "a" || top[1].Files[1] != "b" {
+		t.Fatalf("expected files [a b], got %v", top[1].Files)
+	}
+	if len(p.Top(1)) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(p.Top(1)))
+	}
+}
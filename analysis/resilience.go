@@ -0,0 +1,139 @@
+package analysis
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+// EditOp is the kind of an Edit
+type EditOp int
+
+const (
+	Insert EditOp = iota
+	Delete
+	Replace
+)
+
+func (op EditOp) String() string {
+	switch op {
+	case Insert:
+		return "insert"
+	case Delete:
+		return "delete"
+	case Replace:
+		return "replace"
+	}
+	return fmt.Sprintf("EditOp(%d)", int(op))
+}
+
+// ParseEditOp returns the EditOp named by s
+func ParseEditOp(s string) (EditOp, error) {
+	for _, op := range []EditOp{Insert, Delete, Replace} {
+		if op.String() == s {
+			return op, nil
+		}
+	}
+	return 0, fmt.Errorf("analysis: unknown edit %q", s)
+}
+
+// ErrEdits is returned by ApplyEdits for edits outside the input or
+// overlapping each other
+var ErrEdits = errors.New("analysis: invalid edits")
+
+// Edit inserts, deletes or replaces Length bytes at Offset of the input.
+// Inserted and replacement bytes are pseudorandom.
+type Edit struct {
+	Op     EditOp
+	Offset int
+	Length int
+}
+
+// SpreadEdits returns count edits of the same kind evenly spaced over an
+// input of size bytes
+func SpreadEdits(op EditOp, length, count, size int) []Edit {
+	edits := make([]Edit, count)
+	for i := range edits {
+		edits[i] = Edit{Op: op, Offset: (2*i + 1) * size / (2 * count), Length: length}
+	}
+	return edits
+}
+
+// ApplyEdits returns a copy of base with edits applied. Edit offsets refer
+// to base, and deletions and replacements past its end are cut short.
+// Edits outside base or overlapping each other return ErrEdits.
+func ApplyEdits(base []byte, edits []Edit) ([]byte, error) {
+	sorted := slices.Clone(edits)
+	slices.SortFunc(sorted, func(a, b Edit) int { return cmp.Compare(a.Offset, b.Offset) })
+
+	var out []byte
+	pos := 0
+	for i, e := range sorted {
+		switch {
+		case e.Offset < 0 || e.Offset > len(base) || e.Length < 0:
+			return nil, fmt.Errorf("%w: %s of %d bytes at %d outside %d bytes", ErrEdits, e.Op, e.Length, e.Offset, len(base))
+		case e.Offset < pos:
+			return nil, fmt.Errorf("%w: %s at %d overlaps the edit before it", ErrEdits, e.Op, e.Offset)
+		}
+		out = append(out, base[pos:e.Offset]...)
+		pos = e.Offset
+		switch e.Op {
+		case Insert:
+			out = append(out, datagen.LCG(e.Length, uint32(i))...)
+		case Delete:
+			pos = min(pos+e.Length, len(base))
+		case Replace:
+			n := min(e.Length, len(base)-pos)
+			out = append(out, datagen.LCG(n, uint32(i))...)
+			pos += n
+		}
+	}
+	return append(out, base[pos:]...), nil
+}
+
+// ResilienceResult tells how many chunks of an input survive edits
+type ResilienceResult struct {
+	Params         fastcdc.Params
+	BaseChunks     int
+	EditedChunks   int
+	Surviving      int   // Base chunks still present after edits
+	SurvivingBytes int64 // Total size of surviving chunks
+}
+
+// Survival returns the fraction of base chunks that survived
+func (r ResilienceResult) Survival() float64 {
+	if r.BaseChunks == 0 {
+		return 1
+	}
+	return float64(r.Surviving) / float64(r.BaseChunks)
+}
+
+// Resilience chunks base with p, applies edits, chunks the result again
+// and reports how many chunk hashes survive. This quantifies how well
+// params keep dedup working across small changes.
+func Resilience(base []byte, edits []Edit, p fastcdc.Params) (ResilienceResult, error) {
+	out, err := ApplyEdits(base, edits)
+	if err != nil {
+		return ResilienceResult{}, err
+	}
+	edited := make(map[[sha256.Size]byte]struct{})
+	r := ResilienceResult{Params: p}
+	for _, chunk := range fastcdc.Rechunk(out, p) {
+		edited[sha256.Sum256(chunk.Data)] = struct{}{}
+		r.EditedChunks++
+	}
+
+	for _, chunk := range fastcdc.Rechunk(base, p) {
+		r.BaseChunks++
+		if _, ok := edited[sha256.Sum256(chunk.Data)]; ok {
+			r.Surviving++
+			r.SurvivingBytes += int64(len(chunk.Data))
+		}
+	}
+	return r, nil
+}
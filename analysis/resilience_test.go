@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestApplyEdits(t *testing.T) {
+	base := datagen.LCG(1000, 1)
+	edits := []Edit{
+		{Op: Replace, Offset: 500, Length: 10},
+		{Op: Insert, Offset: 100, Length: 5},
+		{Op: Delete, Offset: 800, Length: 20},
+	}
+
+	out, err := ApplyEdits(base, edits)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(out) != 1000+5-20 {
+		t.Fatalf("expected %d bytes, got %d", 1000+5-20, len(out))
+	}
+	if !bytes.Equal(out[:100], base[:100]) || !bytes.Equal(out[105:505], base[100:500]) {
+		t.Errorf("expected base data before the replacement")
+	}
+	if bytes.Equal(out[505:515], base[500:510]) {
+		t.Errorf("expected replaced bytes to differ")
+	}
+	if !bytes.Equal(out[515:805], base[510:800]) || !bytes.Equal(out[805:], base[820:]) {
+		t.Errorf("expected base data around the deletion")
+	}
+
+	for name, edits := range map[string][]Edit{
+		"overlap":  {{Op: Delete, Offset: 100, Length: 50}, {Op: Insert, Offset: 120, Length: 1}},
+		"negative": {{Op: Insert, Offset: -1, Length: 1}},
+		"past end": {{Op: Replace, Offset: 1001, Length: 1}},
+		"spread":   SpreadEdits(Delete, 200, 10, len(base)),
+	} {
+		if _, err := ApplyEdits(base, edits); !errors.Is(err, ErrEdits) {
+			t.Errorf("%s: expected ErrEdits, got %v", name, err)
+		}
+	}
+}
+
+func TestResilience(t *testing.T) {
+	base := datagen.LCG(4<<20, 42)
+	p := fastcdc.DefaultParams
+
+	if r, err := Resilience(base, nil, p); err != nil || r.Survival() != 1 || r.BaseChunks != r.EditedChunks {
+		t.Errorf("expected all chunks to survive without edits, got %+v", r)
+	}
+
+	// 10 single-byte insertions touch only a few chunks each
+	r, err := Resilience(base, SpreadEdits(Insert, 1, 10, len(base)), p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Surviving < r.BaseChunks-30 {
+		t.Errorf("expected at most 30 lost chunks, got %d of %d surviving", r.Surviving, r.BaseChunks)
+	}
+	if r.Survival() == 1 {
+		t.Errorf("expected some chunks to be lost")
+	}
+}
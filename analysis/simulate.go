@@ -0,0 +1,70 @@
+package analysis
+
+import (
+	"io"
+	"sync"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// Simulate chunks the stream r with every params in configs at the same
+// time and returns a result for each. The stream is read only once and
+// shared between the chunkers, so sweeps over large datasets stay cheap.
+func Simulate(r io.Reader, configs []fastcdc.Params) ([]Result, error) {
+	counters := make([]*counter, len(configs))
+	writers := make([]*io.PipeWriter, len(configs))
+	errs := make([]error, len(configs))
+
+	var wg sync.WaitGroup
+	for i, p := range configs {
+		pr, pw := io.Pipe()
+		counters[i] = newCounter(p)
+		writers[i] = pw
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			chunker := fastcdc.NewChunkerWithParams(pr, p.MinSize, p.AvgSize, p.MaxSize)
+			for {
+				chunk, err := chunker.Next()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					// Unblock the writer, the error is reported below
+					errs[i] = err
+					pr.CloseWithError(err)
+					return
+				}
+				counters[i].add(chunk.Data)
+			}
+		}()
+	}
+
+	mw := make([]io.Writer, len(writers))
+	for i, w := range writers {
+		mw[i] = w
+	}
+	_, err := io.Copy(io.MultiWriter(mw...), r)
+
+	// A nil error closes the pipes with io.EOF
+	for _, w := range writers {
+		w.CloseWithError(err)
+	}
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	results := make([]Result, len(counters))
+	for i, c := range counters {
+		results[i] = c.Result
+	}
+	return results, nil
+}
@@ -0,0 +1,43 @@
+package analysis
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	sample := repeatedSample()
+	grid := Grid(1024, 8*1024, 32*1024)
+
+	results, err := Simulate(bytes.NewReader(sample), grid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Streaming results must match chunking the sample in memory
+	for i, p := range grid {
+		expected := evaluate(sample, p)
+		if results[i] != expected {
+			t.Errorf("expected %+v for %v, got %+v", expected, p, results[i])
+		}
+	}
+}
+
+func TestSimulateReadError(t *testing.T) {
+	readErr := errors.New("read failed")
+	r := io.MultiReader(bytes.NewReader(repeatedSample()), &errReader{readErr})
+
+	if _, err := Simulate(r, Grid(8*1024, 32*1024)); err != readErr {
+		t.Errorf("expected %v, got %v", readErr, err)
+	}
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
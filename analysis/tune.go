@@ -0,0 +1,125 @@
+// Package analysis provides tools for evaluating chunking parameters on
+// real data, such as dedup ratios and metadata overhead.
+package analysis
+
+import (
+	"crypto/sha256"
+	"errors"
+	"runtime"
+	"sync"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// RecordSize is the assumed metadata cost of one chunk reference in bytes,
+// a SHA-256 hash plus an 8-byte length
+const RecordSize = sha256.Size + 8
+
+// ErrOverBudget is returned by Tune when no params meet the metadata budget
+var ErrOverBudget = errors.New("analysis: no params within metadata budget")
+
+// Result holds the outcome of chunking data with one set of params
+type Result struct {
+	Params       fastcdc.Params
+	Chunks       int
+	UniqueChunks int
+	Bytes        int64
+	UniqueBytes  int64
+}
+
+// DedupRatio returns total bytes divided by unique bytes
+func (r Result) DedupRatio() float64 {
+	if r.UniqueBytes == 0 {
+		return 1
+	}
+	return float64(r.Bytes) / float64(r.UniqueBytes)
+}
+
+// MetadataOverhead returns metadata bytes per input byte, assuming
+// RecordSize bytes for each chunk reference
+func (r Result) MetadataOverhead() float64 {
+	if r.Bytes == 0 {
+		return 0
+	}
+	return float64(r.Chunks*RecordSize) / float64(r.Bytes)
+}
+
+// Grid returns params for each average size, with minimum and maximum sizes
+// at the same ratios as fastcdc.DefaultParams
+func Grid(avgSizes ...int) []fastcdc.Params {
+	grid := make([]fastcdc.Params, len(avgSizes))
+	for i, avg := range avgSizes {
+		grid[i] = fastcdc.Params{MinSize: avg / 4, AvgSize: avg, MaxSize: avg * 4}
+	}
+	return grid
+}
+
+// Tune chunks sample with every params in grid in parallel and returns a
+// result for each. The recommended params are the ones with the best dedup
+// ratio whose metadata overhead does not exceed budget; ErrOverBudget is
+// returned if there are none.
+func Tune(sample []byte, grid []fastcdc.Params, budget float64) ([]Result, fastcdc.Params, error) {
+	results := make([]Result, len(grid))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, runtime.NumCPU())
+	for i, p := range grid {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			results[i] = evaluate(sample, p)
+			<-sem
+		}()
+	}
+	wg.Wait()
+
+	best := -1
+	for i, r := range results {
+		if r.MetadataOverhead() > budget {
+			continue
+		}
+		if best < 0 || r.DedupRatio() > results[best].DedupRatio() {
+			best = i
+		}
+	}
+	if best < 0 {
+		return results, fastcdc.Params{}, ErrOverBudget
+	}
+	return results, results[best].Params, nil
+}
+
+// evaluate chunks data with p and counts unique chunks
+func evaluate(data []byte, p fastcdc.Params) Result {
+	c := newCounter(p)
+	for _, chunk := range fastcdc.Rechunk(data, p) {
+		c.add(chunk.Data)
+	}
+	return c.Result
+}
+
+// counter accumulates a Result, telling chunks apart by SHA-256 hash
+type counter struct {
+	Result
+	seen map[[sha256.Size]byte]struct{}
+}
+
+func newCounter(p fastcdc.Params) *counter {
+	return &counter{
+		Result: Result{Params: p},
+		seen:   make(map[[sha256.Size]byte]struct{}),
+	}
+}
+
+func (c *counter) add(data []byte) {
+	c.Chunks++
+	c.Bytes += int64(len(data))
+
+	h := sha256.Sum256(data)
+	if _, ok := c.seen[h]; ok {
+		return
+	}
+	c.seen[h] = struct{}{}
+	c.UniqueChunks++
+	c.UniqueBytes += int64(len(data))
+}
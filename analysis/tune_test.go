@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+// repeatedSample returns 4 copies of a random block with a few edits
+func repeatedSample() []byte {
+	block := datagen.LCG(256*1024, 42)
+
+	var sample []byte
+	for i := 0; i < 4; i++ {
+		sample = append(sample, block...)
+		sample[len(sample)-1000*(i+1)] ^= 0xFF
+	}
+	return sample
+}
+
+func TestTune(t *testing.T) {
+	sample := repeatedSample()
+	grid := Grid(1024, 8*1024, 32*1024)
+
+	results, best, err := Tune(sample, grid, 0.01)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(grid) {
+		t.Fatalf("expected %d results, got %d", len(grid), len(results))
+	}
+
+	for i, r := range results {
+		if r.Params != grid[i] {
+			t.Errorf("result %d has params %v, expected %v", i, r.Params, grid[i])
+		}
+		if r.Bytes != int64(len(sample)) {
+			t.Errorf("result %d covers %d bytes, expected %d", i, r.Bytes, len(sample))
+		}
+		if r.DedupRatio() < 2 {
+			t.Errorf("expected dedup ratio above 2 for %v, got %.2f", r.Params, r.DedupRatio())
+		}
+	}
+
+	// 1 KiB chunks exceed the 1% metadata budget
+	if results[0].MetadataOverhead() <= 0.01 {
+		t.Fatalf("expected 1 KiB chunks to exceed budget, got %.4f", results[0].MetadataOverhead())
+	}
+	if best != grid[1] {
+		t.Errorf("expected best params %v, got %v", grid[1], best)
+	}
+
+	if _, _, err := Tune(sample, grid, 0.0001); err != ErrOverBudget {
+		t.Errorf("expected ErrOverBudget, got %v", err)
+	}
+}
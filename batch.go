@@ -0,0 +1,152 @@
+package fastcdc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// BatchFile is a file packed into the stream chunked by ChunkBatch
+type BatchFile struct {
+	Path   string
+	Offset int // Stream offset of the file contents
+	Size   int
+}
+
+// ChunkBatch chunks the files at paths as one stream of their contents
+// concatenated, so that repositories of many tiny files get chunks of a
+// few files each instead of a chunk per file. It calls fn with each chunk
+// and the files it overlaps. Chunk data is only valid during the call.
+//
+// File edges act as boundary hints. A content-defined cut point moves back
+// to the last edge before it that leaves at least the minimum size, and
+// files of at least the average size get chunks of their own. Chunks then
+// mostly hold whole files, and dedupe when files are added, removed or
+// reordered around them. Empty files overlap no chunks.
+func ChunkBatch(paths []string, fn func(c Chunk, files []BatchFile) error, opts ...Option) error {
+	files := make([]BatchFile, 0, len(paths))
+	size := 0
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		if fi.Size() > 0 {
+			files = append(files, BatchFile{Path: path, Offset: size, Size: int(fi.Size())})
+			size += int(fi.Size())
+		}
+	}
+
+	br := &batchReader{files: files}
+	defer br.close()
+	c := NewChunker(br, opts...)
+	c.policies = append(c.policies, &batchPolicy{files: files, minSize: c.minSize, avgSize: c.avgSize})
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(chunk, batchFiles(files, chunk.Offset, chunk.Length)); err != nil {
+			return err
+		}
+	}
+}
+
+// batchFiles returns the files overlapping length bytes at offset
+func batchFiles(files []BatchFile, offset, length int) []BatchFile {
+	first := sort.Search(len(files), func(i int) bool {
+		return files[i].Offset+files[i].Size > offset
+	})
+	last := sort.Search(len(files), func(i int) bool {
+		return files[i].Offset >= offset+length
+	})
+	return files[first:last]
+}
+
+// batchPolicy moves cut points to the file edges of a batch
+type batchPolicy struct {
+	files   []BatchFile
+	minSize int
+	avgSize int
+}
+
+// Hint returns the start or end of the first file in data of at least the
+// average size
+func (p *batchPolicy) Hint(offset int, data []byte) int {
+	for _, f := range batchFiles(p.files, offset, len(data)) {
+		switch {
+		case f.Size < p.avgSize:
+			continue
+		case f.Offset > offset:
+			return f.Offset - offset
+		case f.Offset+f.Size-offset <= len(data):
+			return f.Offset + f.Size - offset
+		}
+		return 0
+	}
+	return 0
+}
+
+// Adjust moves cutPoint back to the last file edge that leaves at least
+// the minimum size
+func (p *batchPolicy) Adjust(offset int, data []byte, cutPoint int) int {
+	files := batchFiles(p.files, offset, cutPoint)
+	for i := len(files) - 1; i >= 0; i-- {
+		edge := files[i].Offset - offset
+		if edge < p.minSize {
+			break
+		}
+		if edge < cutPoint {
+			return edge
+		}
+	}
+	return cutPoint
+}
+
+// batchReader reads the contents of files one after another
+type batchReader struct {
+	files []BatchFile
+	f     *os.File
+	r     io.Reader // The rest of f, limited to its size when it was listed
+}
+
+func (b *batchReader) Read(p []byte) (int, error) {
+	for {
+		if b.r == nil {
+			if len(b.files) == 0 {
+				return 0, io.EOF
+			}
+			f, err := os.Open(b.files[0].Path)
+			if err != nil {
+				return 0, err
+			}
+			b.f, b.r = f, io.LimitReader(f, int64(b.files[0].Size))
+		}
+		n, err := b.r.Read(p)
+		if err == io.EOF {
+			path := b.files[0].Path
+			remaining := b.r.(*io.LimitedReader).N
+			b.close()
+			b.files = b.files[1:]
+			if remaining > 0 {
+				return n, fmt.Errorf("%s: %w", path, io.ErrUnexpectedEOF)
+			}
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (b *batchReader) close() {
+	if b.f != nil {
+		b.f.Close()
+		b.f, b.r = nil, nil
+	}
+}
@@ -0,0 +1,94 @@
+package fastcdc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeBatch writes files of the given sizes to dir and returns their
+// paths and concatenated contents
+func writeBatch(t *testing.T, dir string, sizes []int, seed uint32) ([]string, []byte) {
+	var paths []string
+	var all []byte
+	for i, size := range sizes {
+		data := make([]byte, size)
+		fillLCG(data, seed+uint32(i))
+		path := filepath.Join(dir, fmt.Sprintf("file%d-%d", seed, i))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+		all = append(all, data...)
+	}
+	return paths, all
+}
+
+func TestChunkBatch(t *testing.T) {
+	dir := t.TempDir()
+	var sizes []int
+	for i := range 300 {
+		sizes = append(sizes, 100+i*37%3000)
+	}
+	sizes[100] = 0
+	sizes[150] = 100 * kiB
+	paths, all := writeBatch(t, dir, sizes, 1)
+
+	var stream []byte
+	chunkBatch := func(paths []string) map[string]bool {
+		sums := map[string]bool{}
+		stream = stream[:0]
+		err := ChunkBatch(paths, func(c Chunk, files []BatchFile) error {
+			stream = append(stream, c.Data...)
+			sums[string(c.Data)] = true
+			if len(files) == 0 || files[0].Offset > c.Offset || files[len(files)-1].Offset+files[len(files)-1].Size < c.Offset+c.Length {
+				return fmt.Errorf("chunk at %d: files %v do not cover it", c.Offset, files)
+			}
+			for _, f := range files {
+				if f.Size == 100*kiB && (f.Offset != c.Offset && len(files) > 1) {
+					return fmt.Errorf("chunk at %d: large file shares a chunk", c.Offset)
+				}
+				if f.Size < DefaultParams.AvgSize && c.Reason != CutMaxSize && c.Reason != CutEOF && c.Offset+c.Length < f.Offset+f.Size && c.Offset+c.Length > f.Offset {
+					return fmt.Errorf("chunk at %d: %s cut by %v", c.Offset, f.Path, c.Reason)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return sums
+	}
+
+	sums := chunkBatch(paths)
+	if len(sums) > len(paths)/3 {
+		t.Fatalf("expected files packed into chunks, got %d chunks for %d files", len(sums), len(paths))
+	}
+	if !bytes.Equal(stream, all) {
+		t.Fatalf("chunks do not match the concatenated files")
+	}
+
+	// Adding files in the middle keeps most chunks
+	extra, _ := writeBatch(t, dir, []int{500, 2000, 700}, 1000)
+	edited := append(append(append([]string{}, paths[:50]...), extra...), paths[50:]...)
+	kept := 0
+	for sum := range chunkBatch(edited) {
+		if sums[sum] {
+			kept++
+		}
+	}
+	if kept < len(sums)-5 {
+		t.Fatalf("expected most of %d chunks kept, got %d", len(sums), kept)
+	}
+
+	// Files shorter than when listed are detected
+	br := &batchReader{files: []BatchFile{{Path: paths[0], Size: sizes[0] + 1}}}
+	defer br.close()
+	if _, err := io.ReadAll(br); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+//go:build js && wasm
+
+// Command fastcdc-wasm exposes the chunker to JavaScript, so that browsers
+// can chunk uploads and send only the chunks a server is missing. Build it
+// with
+//
+//	GOOS=js GOARCH=wasm go build -o fastcdc.wasm ./cmd/fastcdc-wasm
+//
+// and load it with wasm_exec.js from the Go distribution. It defines a
+// global fastcdc object with
+//
+//	fastcdc.chunkBytes(bytes, params) -> chunks
+//	fastcdc.newPushChunker(params) -> {push(bytes) -> chunks, close() -> chunks}
+//
+// where bytes is a Uint8Array, params an optional {min, avg, max} object
+// with sizes as numbers or strings such as "8KiB", and each chunk is
+// {offset, length, hash} with the hex SHA-256 hash. Invalid arguments
+// return {error} with a message instead of a result.
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"syscall/js"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+func main() {
+	js.Global().Set("fastcdc", js.ValueOf(map[string]any{
+		"chunkBytes":     jsFunc(chunkBytes),
+		"newPushChunker": jsFunc(newPushChunker),
+	}))
+	select {}
+}
+
+// jsFunc wraps fn for JavaScript, returning {error} if it fails. A panic
+// would stop the Go program, leaving every function defined by it broken.
+func jsFunc(fn func(args []js.Value) (any, error)) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) (result any) {
+		defer func() {
+			if r := recover(); r != nil {
+				result = errorValue(fmt.Errorf("%v", r))
+			}
+		}()
+		v, err := fn(args)
+		if err != nil {
+			return errorValue(err)
+		}
+		return v
+	})
+}
+
+func errorValue(err error) js.Value {
+	return js.ValueOf(map[string]any{"error": err.Error()})
+}
+
+func chunkBytes(args []js.Value) (any, error) {
+	if len(args) < 1 {
+		return nil, errors.New("fastcdc.chunkBytes: missing bytes")
+	}
+	data, err := bytesArg(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("fastcdc.chunkBytes: %w", err)
+	}
+	p, err := paramsArg(args, 1)
+	if err != nil {
+		return nil, fmt.Errorf("fastcdc.chunkBytes: %w", err)
+	}
+	return chunksValue(fastcdc.Rechunk(data, p)), nil
+}
+
+func newPushChunker(args []js.Value) (any, error) {
+	p, err := paramsArg(args, 0)
+	if err != nil {
+		return nil, fmt.Errorf("fastcdc.newPushChunker: %w", err)
+	}
+	pc := fastcdc.NewPushChunker(fastcdc.WithParams(p))
+	return js.ValueOf(map[string]any{
+		"push": jsFunc(func(args []js.Value) (any, error) {
+			if len(args) < 1 {
+				return nil, errors.New("push: missing bytes")
+			}
+			data, err := bytesArg(args[0])
+			if err != nil {
+				return nil, fmt.Errorf("push: %w", err)
+			}
+			return chunksValue(pc.Push(data)), nil
+		}),
+		"close": jsFunc(func(args []js.Value) (any, error) {
+			return chunksValue(pc.Close()), nil
+		}),
+	}), nil
+}
+
+// bytesArg copies a Uint8Array into Go memory
+func bytesArg(v js.Value) ([]byte, error) {
+	if !v.InstanceOf(js.Global().Get("Uint8Array")) {
+		return nil, errors.New("bytes not a Uint8Array")
+	}
+	data := make([]byte, v.Get("length").Int())
+	js.CopyBytesToGo(data, v)
+	return data, nil
+}
+
+// paramsArg reads optional {min, avg, max} params from args[i]
+func paramsArg(args []js.Value, i int) (fastcdc.Params, error) {
+	p := fastcdc.DefaultParams
+	if i >= len(args) || args[i].IsUndefined() || args[i].IsNull() {
+		return p, nil
+	}
+	if args[i].Type() != js.TypeObject {
+		return p, errors.New("params not an object")
+	}
+	for name, size := range map[string]*int{"min": &p.MinSize, "avg": &p.AvgSize, "max": &p.MaxSize} {
+		switch v := args[i].Get(name); v.Type() {
+		case js.TypeUndefined:
+		case js.TypeNumber:
+			*size = v.Int()
+		case js.TypeString:
+			n, err := fastcdc.ParseSize(v.String())
+			if err != nil {
+				return p, fmt.Errorf("params.%s: %w", name, err)
+			}
+			*size = n
+		default:
+			return p, fmt.Errorf("params.%s not a number or size", name)
+		}
+	}
+	return p, p.Validate()
+}
+
+func chunksValue(chunks []fastcdc.Chunk) js.Value {
+	out := make([]any, len(chunks))
+	for i, c := range chunks {
+		sum := sha256.Sum256(c.Data)
+		out[i] = map[string]any{
+			"offset": c.Offset,
+			"length": c.Length,
+			"hash":   hex.EncodeToString(sum[:]),
+		}
+	}
+	return js.ValueOf(out)
+}
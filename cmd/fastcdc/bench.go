@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/analysis"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+// benchImpls run a chunker implementation over data, calling fn with the
+// data of each chunk
+var benchImpls = map[string]func(data []byte, p fastcdc.Params, fn func([]byte)) error{
+	"chunker": func(data []byte, p fastcdc.Params, fn func([]byte)) error {
+		return eachChunk(fastcdc.NewChunker(bytes.NewReader(data), fastcdc.WithParams(p)), data, fn)
+	},
+	"offsets": func(data []byte, p fastcdc.Params, fn func([]byte)) error {
+		return eachChunk(fastcdc.NewOffsetChunker(bytes.NewReader(data), int64(len(data)), p), data, fn)
+	},
+	"push": func(data []byte, p fastcdc.Params, fn func([]byte)) error {
+		pc := fastcdc.NewPushChunker(fastcdc.WithParams(p))
+		for pos := 0; pos < len(data); pos += 64 << 10 {
+			for _, c := range pc.Push(data[pos:min(pos+64<<10, len(data))]) {
+				fn(c.Data)
+			}
+		}
+		for _, c := range pc.Close() {
+			fn(c.Data)
+		}
+		return nil
+	},
+	"fixed": func(data []byte, p fastcdc.Params, fn func([]byte)) error {
+		return eachChunk(fastcdc.NewFixedChunker(bytes.NewReader(data), p.AvgSize), data, fn)
+	},
+}
+
+var benchHashes = map[string]func() hash.Hash{
+	"none":   nil,
+	"sha256": sha256.New,
+	"crc32c": func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+}
+
+type benchResult struct {
+	Impl         string         `json:"impl"`
+	Hash         string         `json:"hash"`
+	Params       fastcdc.Params `json:"params"`
+	Bytes        int            `json:"bytes"`
+	Chunks       int            `json:"chunks"`
+	Seconds      float64        `json:"seconds"`
+	MBPerSec     float64        `json:"mb_per_sec"`
+	ChunksPerSec float64        `json:"chunks_per_sec"`
+}
+
+func runBench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcdc bench [flags] [input]")
+		fmt.Fprintln(fs.Output(), "\nInput is read into memory first. Without input, pseudorandom data of")
+		fmt.Fprintln(fs.Output(), "-size bytes is used. Each result is the fastest of -count runs.")
+		fs.PrintDefaults()
+	}
+	p := paramFlags(fs)
+	grid := fs.String("grid", "", "comma separated average sizes to run instead of -min, -avg and -max,\nwith min avg/4 and max avg*4")
+	impls := fs.String("impl", "chunker,offsets,push,fixed", "comma separated implementations")
+	hashes := fs.String("hash", "none", "comma separated chunk hashes: none, sha256 or crc32c")
+	count := fs.Int("count", 3, "runs of each configuration")
+	size := sizeValue(64 << 20)
+	fs.Var(&size, "size", "`size` of generated input")
+	jsonOut := fs.Bool("json", false, "print results as JSON lines")
+	fs.Parse(args)
+
+	var data []byte
+	switch fs.NArg() {
+	case 0:
+		data = datagen.LCG(int(size), 42)
+	case 1:
+		var err error
+		if data, err = os.ReadFile(fs.Arg(0)); err != nil {
+			return err
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	configs := []fastcdc.Params{*p}
+	if *grid != "" {
+		var avgs []int
+		for _, s := range strings.Split(*grid, ",") {
+			avg, err := fastcdc.ParseSize(s)
+			if err != nil {
+				return err
+			}
+			avgs = append(avgs, avg)
+		}
+		configs = analysis.Grid(avgs...)
+	}
+	for _, p := range configs {
+		checkParams(fs, p)
+	}
+
+	for _, impl := range strings.Split(*impls, ",") {
+		if _, ok := benchImpls[impl]; !ok {
+			return fmt.Errorf("unknown implementation %q", impl)
+		}
+	}
+	for _, h := range strings.Split(*hashes, ",") {
+		if _, ok := benchHashes[h]; !ok {
+			return fmt.Errorf("unknown hash %q", h)
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	if !*jsonOut {
+		fmt.Printf("%-8s %-7s %22s %10s %10s %12s\n", "impl", "hash", "min/avg/max", "chunks", "MB/s", "chunks/s")
+	}
+	for _, cfg := range configs {
+		for _, impl := range strings.Split(*impls, ",") {
+			for _, h := range strings.Split(*hashes, ",") {
+				r, err := bench(data, cfg, impl, h, *count)
+				if err != nil {
+					return err
+				}
+				if *jsonOut {
+					enc.Encode(r)
+					continue
+				}
+				fmt.Printf("%-8s %-7s %22s %10d %10.1f %12.0f\n", r.Impl, r.Hash,
+					fastcdc.FormatSize(cfg.MinSize)+"/"+fastcdc.FormatSize(cfg.AvgSize)+"/"+fastcdc.FormatSize(cfg.MaxSize),
+					r.Chunks, r.MBPerSec, r.ChunksPerSec)
+			}
+		}
+	}
+	return nil
+}
+
+// bench returns the fastest of count runs of impl with hash h
+func bench(data []byte, p fastcdc.Params, impl, h string, count int) (benchResult, error) {
+	r := benchResult{Impl: impl, Hash: h, Params: p, Bytes: len(data)}
+
+	var hasher hash.Hash
+	if newHash := benchHashes[h]; newHash != nil {
+		hasher = newHash()
+	}
+	for range max(count, 1) {
+		chunks := 0
+		start := time.Now()
+		err := benchImpls[impl](data, p, func(chunk []byte) {
+			if hasher != nil {
+				hasher.Reset()
+				hasher.Write(chunk)
+				hasher.Sum(nil)
+			}
+			chunks++
+		})
+		elapsed := time.Since(start).Seconds()
+		if err != nil {
+			return r, err
+		}
+		if r.Seconds == 0 || elapsed < r.Seconds {
+			r.Seconds = elapsed
+		}
+		r.Chunks = chunks
+	}
+
+	r.MBPerSec = float64(len(data)) / 1e6 / r.Seconds
+	r.ChunksPerSec = float64(r.Chunks) / r.Seconds
+	return r, nil
+}
+
+// eachChunk calls fn with the data of each chunk from s, taking it from
+// data for chunkers that only report offsets
+func eachChunk(s fastcdc.Splitter, data []byte, fn func([]byte)) error {
+	for {
+		c, err := s.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		fn(data[c.Offset : c.Offset+c.Length])
+	}
+}
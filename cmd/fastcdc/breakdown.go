@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/analysis"
+)
+
+func runBreakdown(args []string) error {
+	fs := flag.NewFlagSet("breakdown", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcdc breakdown [flags] file...")
+		fmt.Fprintln(fs.Output(), "\nReports the dedup savings of the files by extension and directory.")
+		fs.PrintDefaults()
+	}
+	p := paramFlags(fs)
+	depth := fs.Int("depth", 2, "directory levels to report, 0 for all")
+	n := fs.Int("n", 20, "number of extensions and directories to report")
+	fs.Parse(args)
+	checkParams(fs, *p)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	b := analysis.NewBreakdown(*depth)
+	err := fastcdc.ChunkFiles(fs.Args(), 0, func(path string, c fastcdc.Chunk) error {
+		b.Add(path, c.Data)
+		return nil
+	}, fastcdc.WithParams(*p))
+	if err != nil {
+		return err
+	}
+
+	printGroups("extension", b.ByExtension(), *n)
+	fmt.Println()
+	printGroups("directory", b.ByDirectory(), *n)
+	return nil
+}
+
+func printGroups(title string, groups []analysis.Group, n int) {
+	fmt.Printf("%-30s %8s %14s %14s %8s\n", title, "files", "bytes", "dup bytes", "dedup")
+	for _, g := range groups[:min(n, len(groups))] {
+		name := g.Name
+		if name == "" {
+			name = "(none)"
+		}
+		fmt.Printf("%-30s %8d %14d %14d %7.2fx\n", name, g.Files, g.Bytes, g.DupBytes, g.DedupRatio())
+	}
+}
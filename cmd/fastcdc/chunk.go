@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/framed"
+)
+
+type chunkRecord struct {
+	Offset int    `json:"offset"`
+	Length int    `json:"length"`
+	Hash   string `json:"hash,omitempty"`
+	Reason string `json:"reason"`
+}
+
+func runChunk(args []string) error {
+	fs := flag.NewFlagSet("chunk", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcdc chunk [flags] [input]")
+		fmt.Fprintln(fs.Output(), "\nPrints each chunk of input, or of stdin without input, as it is found.")
+		fmt.Fprintln(fs.Output(), "With -encode, writes the input as a framed stream for fastcdc decode instead.")
+		fs.PrintDefaults()
+	}
+	p := paramFlags(fs)
+	hashName := fs.String("hash", "sha256", "chunk hash: none, sha256 or crc32c")
+	output := fs.String("output", "text", "output format: text or jsonl, one JSON object per chunk")
+	encode := fs.Bool("encode", false, "write a framed stream with repeated chunks as references")
+	window := sizeValue(framed.DefaultWindow)
+	fs.Var(&window, "window", "how far back in the stream -encode references chunks")
+	fs.Parse(args)
+	checkParams(fs, *p)
+
+	newHash, ok := benchHashes[*hashName]
+	if !ok {
+		return fmt.Errorf("unknown hash %q", *hashName)
+	}
+	if *output != "text" && *output != "jsonl" {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+
+	var r io.Reader = os.Stdin
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *encode {
+		_, err := framed.Encode(os.Stdout, r, int64(window), fastcdc.WithParams(*p))
+		return err
+	}
+
+	opts := []fastcdc.Option{fastcdc.WithParams(*p)}
+	if newHash != nil {
+		opts = append(opts, fastcdc.WithHash(newHash))
+	}
+	c := fastcdc.NewChunker(r, opts...)
+
+	w := bufio.NewWriter(os.Stdout)
+	enc := json.NewEncoder(w)
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		rec := chunkRecord{
+			Offset: chunk.Offset,
+			Length: chunk.Length,
+			Hash:   hex.EncodeToString(chunk.Sum),
+			Reason: chunk.Reason.String(),
+		}
+		if *output == "jsonl" {
+			err = enc.Encode(rec)
+		} else {
+			_, err = fmt.Fprintln(w, strings.TrimSpace(fmt.Sprintf("%d\t%d\t%s\t%s", rec.Offset, rec.Length, rec.Reason, rec.Hash)))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jokkebk/go-fastcdc/conformance"
+)
+
+func runConformance(args []string) error {
+	fs := flag.NewFlagSet("conformance", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcdc conformance [flags] -list chunks.txt input")
+		fs.PrintDefaults()
+	}
+	p := paramFlags(fs)
+	list := fs.String("list", "", "chunk list from another implementation")
+	context := fs.Int("context", 32, "bytes of input to show around a divergence")
+	fs.Parse(args)
+	checkParams(fs, *p)
+
+	if *list == "" || fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(*list)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cuts, err := conformance.ReadChunkList(f)
+	if err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	d, err := conformance.Compare(data, cuts, *p, *context)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		fmt.Printf("all %d chunks match\n", len(cuts))
+		return nil
+	}
+
+	fmt.Printf("chunk %d diverges: expected end at %d, got %d\n", d.Index, d.Expected, d.Got)
+	fmt.Printf("input from offset %d:\n", d.ContextOffset)
+	fmt.Print(hex.Dump(d.Context))
+	return errors.New("chunk lists diverge")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jokkebk/go-fastcdc/framed"
+)
+
+func runDecode(args []string) error {
+	fs := flag.NewFlagSet("decode", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcdc decode < stream > output")
+		fmt.Fprintln(fs.Output(), "\nDecodes a framed stream from fastcdc chunk -encode, checking its hash.")
+		fmt.Fprintln(fs.Output(), "Output is written as it is decoded, so discard it on error.")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	if err := framed.Decode(w, os.Stdin); err != nil {
+		w.Flush()
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/analysis"
+)
+
+func runGearcheck(args []string) error {
+	fs := flag.NewFlagSet("gearcheck", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcdc gearcheck [flags]")
+		fmt.Fprintln(fs.Output(), "\nChecks the built-in gear table unless -seed or -table is given.")
+		fs.PrintDefaults()
+	}
+	p := paramFlags(fs)
+	seed := fs.Uint64("seed", 0, "check the table generated from this seed")
+	file := fs.String("table", "", "check the table in this file, 256 hex values")
+	fs.Parse(args)
+	checkParams(fs, *p)
+
+	table := &fastcdc.G
+	switch {
+	case *file != "":
+		var err error
+		if table, err = readGearTable(*file); err != nil {
+			return err
+		}
+	case *seed != 0:
+		table = fastcdc.GearTable(*seed)
+	}
+
+	r := analysis.CheckGear(table, *p)
+
+	lo, hi := 1.0, 0.0
+	for _, b := range r.BitBalance {
+		lo, hi = min(lo, b), max(hi, b)
+	}
+	fmt.Printf("bit balance:        %.1f%% to %.1f%%\n", 100*lo, 100*hi)
+	fmt.Printf("duplicate entries:  %d\n", r.Duplicates)
+	fmt.Printf("small mask:         %d collisions, %.1f expected\n", r.CollisionsS, r.ExpectedCollisionsS)
+	fmt.Printf("large mask:         %d collisions, %.1f expected\n", r.CollisionsL, r.ExpectedCollisionsL)
+	fmt.Printf("average chunk size: %.0f, built-in table %.0f\n", r.AvgChunkSize, r.RefAvgChunkSize)
+
+	warnings := r.Warnings()
+	for _, w := range warnings {
+		fmt.Println("warning:", w)
+	}
+	if len(warnings) > 0 {
+		return fmt.Errorf("%d problems found", len(warnings))
+	}
+	fmt.Println("table looks sound")
+	return nil
+}
+
+// readGearTable reads 256 whitespace or comma separated hex values
+func readGearTable(path string) (*[256]uint64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.FieldsFunc(string(data), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+	if len(fields) != 256 {
+		return nil, fmt.Errorf("%s: expected 256 values, got %d", path, len(fields))
+	}
+
+	var table [256]uint64
+	for i, f := range fields {
+		if table[i], err = strconv.ParseUint(strings.TrimPrefix(f, "0x"), 16, 64); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return &table, nil
+}
@@ -0,0 +1,176 @@
+// Command fastcdc provides tools for working with content-defined chunking.
+//
+// Usage:
+//
+//	fastcdc <command> [flags] [args]
+//
+// Run a command with -h for its flags.
+//
+// Commands taking chunk sizes read defaults from the JSON config file named
+// by $FASTCDC_CONFIG or -config, such as
+//
+//	{"preset": "default-8k", "max_size": "64KiB"}
+//
+// Only JSON is supported, not TOML or YAML, and there is no serve mode to
+// configure.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+type command struct {
+	run  func(args []string) error
+	help string
+}
+
+var commands = map[string]command{
+	"bench":       {runBench, "measure chunking throughput"},
+	"breakdown":   {runBreakdown, "report dedup savings by file extension and directory"},
+	"chunk":       {runChunk, "print the chunks of a file or stdin"},
+	"conformance": {runConformance, "compare a chunk list from another implementation"},
+	"decode":      {runDecode, "decode a framed stream from chunk -encode"},
+	"gearcheck":   {runGearcheck, "check statistical quality of a gear table"},
+	"popular":     {runPopular, "report the most referenced chunks of files"},
+	"resilience":  {runResilience, "measure how many chunks survive edits"},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	name := os.Args[1]
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "fastcdc: unknown command %q\n", name)
+		usage()
+		os.Exit(2)
+	}
+
+	if err := cmd.run(os.Args[2:]); err != nil {
+		fmt.Fprintf(os.Stderr, "fastcdc %s: %v\n", name, err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: fastcdc <command> [flags] [args]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].help)
+	}
+}
+
+// paramFlags registers chunk size flags on fs, defaulting to DefaultParams
+// or the config file named by $FASTCDC_CONFIG. The -preset and -config
+// flags replace all three sizes, so later flags override them.
+func paramFlags(fs *flag.FlagSet) *fastcdc.Params {
+	p := fastcdc.DefaultParams
+	if path := os.Getenv("FASTCDC_CONFIG"); path != "" {
+		if err := loadConfig(path, &p); err != nil {
+			fmt.Fprintf(os.Stderr, "fastcdc: FASTCDC_CONFIG: %v\n", err)
+			os.Exit(2)
+		}
+	}
+	fs.Func("preset", "named chunk sizes: "+strings.Join(fastcdc.PresetNames(), ", "), func(name string) (err error) {
+		p, err = fastcdc.Preset(name)
+		return err
+	})
+	fs.Func("config", "JSON file with a preset and/or min_size, avg_size, max_size", func(path string) error {
+		return loadConfig(path, &p)
+	})
+	fs.Var((*sizeValue)(&p.MinSize), "min", "minimum chunk `size`")
+	fs.Var((*sizeValue)(&p.AvgSize), "avg", "average chunk `size`")
+	fs.Var((*sizeValue)(&p.MaxSize), "max", "maximum chunk `size`")
+	return &p
+}
+
+// checkParams exits with a usage error if p, as resolved from the flags
+// of fs, is not valid
+func checkParams(fs *flag.FlagSet, p fastcdc.Params) {
+	if err := p.Validate(); err != nil {
+		fmt.Fprintln(fs.Output(), err)
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// loadConfig sets p from a JSON config file. Sizes given in the file
+// override those of its preset, and the result must be valid.
+func loadConfig(path string, p *fastcdc.Params) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var cfg struct {
+		Preset  string     `json:"preset"`
+		MinSize *sizeValue `json:"min_size"`
+		AvgSize *sizeValue `json:"avg_size"`
+		MaxSize *sizeValue `json:"max_size"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.Preset != "" {
+		if *p, err = fastcdc.Preset(cfg.Preset); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, f := range []struct {
+		v   *sizeValue
+		dst *int
+	}{{cfg.MinSize, &p.MinSize}, {cfg.AvgSize, &p.AvgSize}, {cfg.MaxSize, &p.MaxSize}} {
+		if f.v != nil {
+			*f.dst = int(*f.v)
+		}
+	}
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// sizeValue is a byte count given as a number or a string for
+// fastcdc.ParseSize, such as "8KiB"
+type sizeValue int
+
+func (v *sizeValue) String() string {
+	if v == nil {
+		return "0"
+	}
+	return fastcdc.FormatSize(int(*v))
+}
+
+func (v *sizeValue) Set(s string) error {
+	n, err := fastcdc.ParseSize(s)
+	*v = sizeValue(n)
+	return err
+}
+
+func (v *sizeValue) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return err
+		}
+		*v = sizeValue(n)
+		return nil
+	}
+	return v.Set(s)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/analysis"
+)
+
+func runPopular(args []string) error {
+	fs := flag.NewFlagSet("popular", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcdc popular [flags] file...")
+		fmt.Fprintln(fs.Output(), "\nReports the chunks of the files that occur most often.")
+		fs.PrintDefaults()
+	}
+	p := paramFlags(fs)
+	n := fs.Int("n", 20, "number of chunks to report")
+	files := fs.Int("files", 3, "files to list for each chunk")
+	fs.Parse(args)
+	checkParams(fs, *p)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	pop := analysis.NewPopularity(*files)
+	err := fastcdc.ChunkFiles(fs.Args(), 0, func(path string, c fastcdc.Chunk) error {
+		pop.Add(path, c.Data)
+		return nil
+	}, fastcdc.WithParams(*p))
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%-16s %8s %8s %12s  %s\n", "hash", "refs", "length", "saved", "files")
+	for _, c := range pop.Top(*n) {
+		name := hex.EncodeToString(c.Hash[:8])
+		if c.Zero {
+			name = "zeros"
+		}
+		fmt.Printf("%-16s %8d %8d %12d  %s\n", name, c.Refs, c.Length, c.Saved(), strings.Join(c.Files, " "))
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/jokkebk/go-fastcdc/analysis"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func runResilience(args []string) error {
+	fs := flag.NewFlagSet("resilience", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fastcdc resilience [flags] [input]")
+		fmt.Fprintln(fs.Output(), "\nWithout input, pseudorandom data of -size bytes is used.")
+		fs.PrintDefaults()
+	}
+	p := paramFlags(fs)
+	op := fs.String("op", "insert", "edit to apply: insert, delete or replace")
+	length := fs.Int("n", 1, "bytes changed by each edit")
+	count := fs.Int("count", 10, "number of edits, spread evenly over the input")
+	size := sizeValue(16 << 20)
+	fs.Var(&size, "size", "`size` of generated input")
+	fs.Parse(args)
+	checkParams(fs, *p)
+
+	editOp, err := analysis.ParseEditOp(*op)
+	if err != nil {
+		return err
+	}
+
+	var base []byte
+	switch fs.NArg() {
+	case 0:
+		base = datagen.LCG(int(size), 42)
+	case 1:
+		if base, err = os.ReadFile(fs.Arg(0)); err != nil {
+			return err
+		}
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	edits := analysis.SpreadEdits(editOp, *length, *count, len(base))
+	r, err := analysis.Resilience(base, edits, *p)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("params:    min %d, avg %d, max %d\n", p.MinSize, p.AvgSize, p.MaxSize)
+	fmt.Printf("edits:     %d x %s %d bytes\n", *count, editOp, *length)
+	fmt.Printf("chunks:    %d before, %d after\n", r.BaseChunks, r.EditedChunks)
+	fmt.Printf("surviving: %d chunks (%.2f%%), %d bytes\n", r.Surviving, 100*r.Survival(), r.SurvivingBytes)
+	return nil
+}
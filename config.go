@@ -0,0 +1,45 @@
+package fastcdc
+
+import mathbits "math/bits"
+
+// Normalization is the number of bits added to the mask used before the
+// average size, and removed from the one used after it
+const Normalization = 2
+
+// Variants of the scan, as reported by Config
+const (
+	VariantFastCDC = "fastcdc"       // Gear hash with two masks
+	VariantTwoByte = "fastcdc-2byte" // With WithTwoByteScan
+)
+
+// Config is the effective configuration of a Chunker, for recording in
+// logs, manifests and bug reports how a stream was chunked
+type Config struct {
+	Params
+	Normalization int    `json:"normalization"`
+	MaskSBits     int    `json:"mask_s_bits"` // Bits set in the mask before avg size
+	MaskLBits     int    `json:"mask_l_bits"` // Bits set in the mask after it
+	Variant       string `json:"variant"`
+	CustomGear    bool   `json:"custom_gear"` // Whether a gear table other than G is used
+}
+
+// Params returns the chunk sizes in use, after all options
+func (c *Chunker) Params() Params {
+	return Params{MinSize: c.minSize, AvgSize: c.avgSize, MaxSize: c.maxSize}
+}
+
+// Config returns the effective configuration of the Chunker
+func (c *Chunker) Config() Config {
+	variant := VariantFastCDC
+	if c.twoByte {
+		variant = VariantTwoByte
+	}
+	return Config{
+		Params:        c.Params(),
+		Normalization: Normalization,
+		MaskSBits:     mathbits.OnesCount64(c.maskS),
+		MaskLBits:     mathbits.OnesCount64(c.maskL),
+		Variant:       variant,
+		CustomGear:    c.gear != nil && *c.gear != G,
+	}
+}
@@ -0,0 +1,33 @@
+package fastcdc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestConfig(t *testing.T) {
+	p := Params{MinSize: 1 * kiB, AvgSize: 4 * kiB, MaxSize: 16 * kiB}
+	c := NewChunker(nil, WithParams(p))
+	if c.Params() != p {
+		t.Errorf("expected params %v, got %v", p, c.Params())
+	}
+
+	expected := Config{Params: p, Normalization: 2, MaskSBits: 14, MaskLBits: 10, Variant: VariantFastCDC}
+	if cfg := c.Config(); cfg != expected {
+		t.Errorf("expected config %+v, got %+v", expected, cfg)
+	}
+
+	cfg := NewChunker(nil, WithTwoByteScan(), WithGearTable(GearTable(1))).Config()
+	if cfg.Variant != VariantTwoByte || !cfg.CustomGear || cfg.Params != DefaultParams {
+		t.Errorf("unexpected config %+v", cfg)
+	}
+	if NewChunker(nil, WithGearTable(&G)).Config().CustomGear {
+		t.Error("expected the default table not to count as custom")
+	}
+
+	b, err := json.Marshal(cfg)
+	if err != nil || !strings.Contains(string(b), `"min_size":2048`) || !strings.Contains(string(b), `"mask_s_bits":15`) {
+		t.Errorf("unexpected JSON %s (%v)", b, err)
+	}
+}
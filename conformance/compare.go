@@ -0,0 +1,113 @@
+package conformance
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// Divergence describes the first chunk boundary where a chunk list from
+// another implementation differs from this package. Expected or Got is -1
+// if the corresponding list was too short.
+type Divergence struct {
+	Index    int
+	Expected int
+	Got      int
+
+	ContextOffset int    // Stream offset of Context
+	Context       []byte // Input bytes around the divergence
+}
+
+func (d *Divergence) Error() string {
+	return fmt.Sprintf("conformance: chunk %d ends at %d, expected %d", d.Index, d.Got, d.Expected)
+}
+
+// ReadChunkList parses a chunk list produced by another implementation and
+// returns the end offset of each chunk. Each non-empty line describes one
+// chunk either as "offset length" columns separated by whitespace or
+// commas, with any further columns like hashes ignored, or as key=value
+// fields with offset and size or length keys, as printed by the fastcdc-rs
+// examples. Lines starting with # are skipped.
+func ReadChunkList(r io.Reader) ([]int, error) {
+	var cuts []int
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		offset, length, err := parseChunkLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("conformance: line %d: %w", line, err)
+		}
+		cuts = append(cuts, offset+length)
+	}
+	return cuts, scanner.Err()
+}
+
+func parseChunkLine(text string) (offset, length int, err error) {
+	fields := strings.FieldsFunc(text, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	})
+
+	if strings.Contains(text, "=") {
+		offset, length = -1, -1
+		for _, f := range fields {
+			key, value, _ := strings.Cut(f, "=")
+			switch key {
+			case "offset":
+				offset, err = strconv.Atoi(value)
+			case "size", "length":
+				length, err = strconv.Atoi(value)
+			}
+			if err != nil {
+				return 0, 0, err
+			}
+		}
+		if offset < 0 || length < 0 {
+			return 0, 0, fmt.Errorf("missing offset or size in %q", text)
+		}
+		return offset, length, nil
+	}
+
+	if len(fields) < 2 {
+		return 0, 0, fmt.Errorf("expected offset and length in %q", text)
+	}
+	if offset, err = strconv.Atoi(fields[0]); err != nil {
+		return 0, 0, err
+	}
+	if length, err = strconv.Atoi(fields[1]); err != nil {
+		return 0, 0, err
+	}
+	return offset, length, nil
+}
+
+// Compare chunks data with params p and compares the result against cuts
+// from another implementation. It returns nil if all boundaries match, or
+// the first divergence with up to context bytes of input on either side.
+func Compare(data []byte, cuts []int, p fastcdc.Params, context int) (*Divergence, error) {
+	expected, err := Reference(data, p)
+	if err != nil {
+		return nil, err
+	}
+
+	m := compare("", expected, cuts)
+	if m == nil {
+		return nil, nil
+	}
+
+	d := &Divergence{Index: m.Index, Expected: m.Expected, Got: m.Got}
+	at := d.Expected
+	if at < 0 || (d.Got >= 0 && d.Got < at) {
+		at = d.Got
+	}
+	at = min(max(at, 0), len(data))
+	d.ContextOffset = max(at-context, 0)
+	d.Context = data[d.ContextOffset:min(at+context, len(data))]
+	return d, nil
+}
@@ -0,0 +1,64 @@
+package conformance
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadChunkList(t *testing.T) {
+	input := `# offset length hash
+0 100 abcd
+100,50
+hash=1234 offset=150 size=25
+
+offset=175 length=5
+`
+	cuts, err := ReadChunkList(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []int{100, 150, 175, 180}
+	if len(cuts) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, cuts)
+	}
+	for i := range cuts {
+		if cuts[i] != expected[i] {
+			t.Errorf("expected cut %d at index %d, got %d", expected[i], i, cuts[i])
+		}
+	}
+
+	for _, bad := range []string{"100", "a b", "offset=5", "size=x offset=1"} {
+		if _, err := ReadChunkList(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	v := Vectors()[0]
+	data, err := v.Input.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Compare(data, v.Cuts, v.Params, 16)
+	if err != nil || d != nil {
+		t.Fatalf("expected no divergence, got %v, %v", d, err)
+	}
+
+	// Move the third boundary a bit forward
+	cuts := append([]int(nil), v.Cuts...)
+	cuts[2] += 10
+
+	d, err = Compare(data, cuts, v.Params, 16)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d == nil || d.Index != 2 || d.Expected != v.Cuts[2] || d.Got != cuts[2] {
+		t.Fatalf("unexpected divergence: %+v", d)
+	}
+	if d.ContextOffset != v.Cuts[2]-16 || string(d.Context) != string(data[v.Cuts[2]-16:v.Cuts[2]+16]) {
+		t.Errorf("unexpected context at %d: %x", d.ContextOffset, d.Context)
+	}
+}
@@ -0,0 +1,121 @@
+// Package conformance provides golden test vectors for FastCDC chunking,
+// so that other implementations and future refactors can prove that they
+// produce the same chunk boundaries as this package.
+package conformance
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+//go:embed vectors.json
+var vectorsJSON []byte
+
+// Recipe describes how to generate the input of a test vector
+type Recipe struct {
+	Generator string `json:"generator"` // Only "lcg" for now
+	Seed      uint32 `json:"seed"`
+	Size      int    `json:"size"`
+}
+
+// Vector is an input recipe with chunking params and expected chunk ends
+type Vector struct {
+	Name   string         `json:"name"`
+	Input  Recipe         `json:"input"`
+	Params fastcdc.Params `json:"params"`
+	Cuts   []int          `json:"cuts"`
+}
+
+// Impl chunks data with params p and returns the end offset of each chunk
+type Impl func(data []byte, p fastcdc.Params) ([]int, error)
+
+// Mismatch describes the first chunk boundary where an Impl diverges from
+// a test vector. Expected or Got is -1 if the list was too short.
+type Mismatch struct {
+	Vector   string
+	Index    int
+	Expected int
+	Got      int
+}
+
+func (m *Mismatch) Error() string {
+	return fmt.Sprintf("conformance: %s: chunk %d ends at %d, expected %d",
+		m.Vector, m.Index, m.Got, m.Expected)
+}
+
+// Vectors returns the embedded test vectors
+func Vectors() []Vector {
+	var vectors []Vector
+	if err := json.Unmarshal(vectorsJSON, &vectors); err != nil {
+		panic("conformance: invalid embedded vectors: " + err.Error())
+	}
+	return vectors
+}
+
+// Generate returns the input described by the recipe
+func (r Recipe) Generate() ([]byte, error) {
+	switch r.Generator {
+	case "lcg":
+		return datagen.LCG(r.Size, r.Seed), nil
+	}
+	return nil, fmt.Errorf("conformance: unknown generator %q", r.Generator)
+}
+
+// Verify runs impl on every test vector and returns a *Mismatch for the
+// first divergence, or an error returned by impl
+func Verify(impl Impl) error {
+	for _, v := range Vectors() {
+		data, err := v.Input.Generate()
+		if err != nil {
+			return err
+		}
+		cuts, err := impl(data, v.Params)
+		if err != nil {
+			return fmt.Errorf("conformance: %s: %w", v.Name, err)
+		}
+		if m := compare(v.Name, v.Cuts, cuts); m != nil {
+			return m
+		}
+	}
+	return nil
+}
+
+// Reference is the Impl of this package, used to generate the vectors
+func Reference(data []byte, p fastcdc.Params) ([]int, error) {
+	chunker := fastcdc.NewChunkerWithParams(bytes.NewReader(data), p.MinSize, p.AvgSize, p.MaxSize)
+
+	var cuts []int
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			return cuts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		cuts = append(cuts, chunk.Offset+len(chunk.Data))
+	}
+}
+
+// compare returns the first difference between expected and got cuts
+func compare(name string, expected, got []int) *Mismatch {
+	for i := 0; i < max(len(expected), len(got)); i++ {
+		m := &Mismatch{Vector: name, Index: i, Expected: -1, Got: -1}
+		if i < len(expected) {
+			m.Expected = expected[i]
+		}
+		if i < len(got) {
+			m.Got = got[i]
+		}
+		if m.Expected != m.Got {
+			return m
+		}
+	}
+	return nil
+}
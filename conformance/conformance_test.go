@@ -0,0 +1,77 @@
+package conformance
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+var update = flag.Bool("update", false, "regenerate vectors.json with the reference implementation")
+
+// recipes are the inputs and params of the embedded vectors
+var recipes = []Vector{
+	{Name: "lcg-1m-default", Input: Recipe{"lcg", 42, 1 << 20}, Params: fastcdc.DefaultParams},
+	{Name: "lcg-1m-32k", Input: Recipe{"lcg", 42, 1 << 20}, Params: fastcdc.Params{MinSize: 8 << 10, AvgSize: 32 << 10, MaxSize: 128 << 10}},
+	{Name: "lcg-256k-1k", Input: Recipe{"lcg", 7, 256 << 10}, Params: fastcdc.Params{MinSize: 256, AvgSize: 1 << 10, MaxSize: 4 << 10}},
+	{Name: "lcg-4m-64k", Input: Recipe{"lcg", 1, 4 << 20}, Params: fastcdc.Params{MinSize: 16 << 10, AvgSize: 64 << 10, MaxSize: 256 << 10}},
+	{Name: "lcg-small", Input: Recipe{"lcg", 3, 1000}, Params: fastcdc.DefaultParams},
+}
+
+func TestUpdateVectors(t *testing.T) {
+	if !*update {
+		t.Skip("run with -update to regenerate vectors")
+	}
+
+	vectors := make([]Vector, len(recipes))
+	for i, v := range recipes {
+		data, err := v.Input.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v.Cuts, err = Reference(data, v.Params); err != nil {
+			t.Fatal(err)
+		}
+		vectors[i] = v
+	}
+
+	out, err := json.MarshalIndent(vectors, "", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("vectors.json", append(out, '\n'), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	if err := Verify(Reference); err != nil {
+		t.Fatalf("reference does not conform: %v", err)
+	}
+
+	// The 32 KiB vector matches the offsets of the package tests
+	v := Vectors()[1]
+	if v.Cuts[0] != 36714 || v.Cuts[len(v.Cuts)-1] != 1048576 {
+		t.Errorf("unexpected cuts for %s: %v", v.Name, v.Cuts)
+	}
+}
+
+func TestVerifyMismatch(t *testing.T) {
+	// Dropping the last chunk boundary must be reported
+	truncated := func(data []byte, p fastcdc.Params) ([]int, error) {
+		cuts, err := Reference(data, p)
+		return cuts[:len(cuts)-1], err
+	}
+
+	err := Verify(truncated)
+	m, ok := err.(*Mismatch)
+	if !ok {
+		t.Fatalf("expected *Mismatch, got %v", err)
+	}
+	v := Vectors()[0]
+	if m.Vector != v.Name || m.Index != len(v.Cuts)-1 || m.Got != -1 {
+		t.Errorf("unexpected mismatch: %v", m)
+	}
+}
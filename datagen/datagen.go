@@ -0,0 +1,59 @@
+// Package datagen generates deterministic pseudorandom data for
+// reproducible dedup tests and benchmarks. The generators are the same ones
+// used by the tests and conformance vectors of this module.
+package datagen
+
+// FillLCG fills data with pseudorandom bytes from a linear congruential
+// generator. The output repeats after 16 MiB.
+func FillLCG(data []byte, seed uint32) {
+	const m = 1 << 31
+	const a = 1103515245
+	const c = 12345
+
+	for i := range data {
+		seed = (a*seed + c) % m
+		data[i] = byte((seed >> 16) & 0xFF)
+	}
+}
+
+// LCG returns size bytes generated by FillLCG
+func LCG(size int, seed uint32) []byte {
+	data := make([]byte, size)
+	FillLCG(data, seed)
+	return data
+}
+
+// Repeated returns size bytes consisting of copies of a single pseudorandom
+// block of blockSize bytes, the best case for dedup
+func Repeated(size, blockSize int, seed uint32) []byte {
+	block := LCG(blockSize, seed)
+	data := make([]byte, size)
+	for i := 0; i < size; i += blockSize {
+		copy(data[i:], block)
+	}
+	return data
+}
+
+// Shifted returns a copy of data with n pseudorandom bytes inserted at
+// offset at, which shifts everything after it. Chunk boundaries should
+// resynchronize shortly after the insertion.
+func Shifted(data []byte, at, n int, seed uint32) []byte {
+	out := make([]byte, 0, len(data)+n)
+	out = append(out, data[:at]...)
+	out = append(out, LCG(n, seed)...)
+	return append(out, data[at:]...)
+}
+
+// Mixed returns size bytes alternating between pseudorandom segments and
+// repetitive low-entropy text segments, each segSize bytes long
+func Mixed(size, segSize int, seed uint32) []byte {
+	const text = "the quick brown fox jumps over the lazy dog "
+
+	data := LCG(size, seed)
+	for i := segSize; i < size; i += 2 * segSize {
+		for j := i; j < min(i+segSize, size); j++ {
+			data[j] = text[(j-i)%len(text)]
+		}
+	}
+	return data
+}
@@ -0,0 +1,53 @@
+package datagen
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLCG(t *testing.T) {
+	data := LCG(16, 42)
+	if !bytes.Equal(data, LCG(16, 42)) {
+		t.Fatalf("LCG is not deterministic")
+	}
+	if bytes.Equal(data, LCG(16, 43)) {
+		t.Errorf("different seeds give the same data")
+	}
+
+	// Repeats after 16 MiB
+	long := LCG(16<<20+16, 42)
+	if !bytes.Equal(long[:16], long[16<<20:]) {
+		t.Errorf("expected LCG to repeat after 16 MiB")
+	}
+}
+
+func TestRepeated(t *testing.T) {
+	data := Repeated(10000, 3000, 1)
+	if len(data) != 10000 {
+		t.Fatalf("expected 10000 bytes, got %d", len(data))
+	}
+	if !bytes.Equal(data[:3000], data[3000:6000]) || !bytes.Equal(data[:1000], data[9000:]) {
+		t.Errorf("expected repeated blocks")
+	}
+}
+
+func TestShifted(t *testing.T) {
+	base := LCG(1000, 1)
+	data := Shifted(base, 100, 10, 2)
+	if len(data) != 1010 {
+		t.Fatalf("expected 1010 bytes, got %d", len(data))
+	}
+	if !bytes.Equal(data[:100], base[:100]) || !bytes.Equal(data[110:], base[100:]) {
+		t.Errorf("expected base data around the insertion")
+	}
+}
+
+func TestMixed(t *testing.T) {
+	data := Mixed(1000, 100, 1)
+	if !bytes.Equal(data[:100], LCG(100, 1)) {
+		t.Errorf("expected random first segment")
+	}
+	if !bytes.HasPrefix(data[100:], []byte("the quick")) || !bytes.HasPrefix(data[300:], []byte("the quick")) {
+		t.Errorf("expected text in odd segments")
+	}
+}
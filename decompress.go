@@ -0,0 +1,78 @@
+package fastcdc
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+)
+
+// Encodings detected by WithTransparentDecompression
+const (
+	EncodingNone = ""
+	EncodingGzip = "gzip"
+)
+
+// ErrEncoding is returned for compressed input that cannot be decompressed,
+// such as zstd, for which the standard library has no decoder
+var ErrEncoding = errors.New("fastcdc: unsupported input encoding")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// WithTransparentDecompression makes the Chunker detect gzip input from its
+// magic bytes and chunk the decompressed stream instead, since chunks of
+// compressed data hardly ever repeat. Offsets are then in the decompressed
+// stream. Other input is chunked as is, except zstd input, which makes
+// Next return ErrEncoding. Encoding reports what was detected.
+func WithTransparentDecompression() Option {
+	return func(c *Chunker) {
+		c.decompress = true
+	}
+}
+
+// Encoding returns the encoding of the input detected by
+// WithTransparentDecompression, once Next has been called
+func (c *Chunker) Encoding() string {
+	if c.dec == nil {
+		return EncodingNone
+	}
+	return c.dec.encoding
+}
+
+// decompressReader detects the encoding of r on the first read
+type decompressReader struct {
+	r        io.Reader
+	encoding string
+	detected bool
+}
+
+func (d *decompressReader) Read(p []byte) (int, error) {
+	if !d.detected {
+		d.detected = true
+		br := bufio.NewReader(d.r)
+		head, _ := br.Peek(len(zstdMagic))
+		d.r = br
+		switch {
+		case bytes.HasPrefix(head, gzipMagic):
+			zr, err := gzip.NewReader(br)
+			if err != nil {
+				d.r = errReader{err}
+				return 0, err
+			}
+			d.r, d.encoding = zr, EncodingGzip
+		case bytes.HasPrefix(head, zstdMagic):
+			d.r = errReader{ErrEncoding}
+		}
+	}
+	return d.r.Read(p)
+}
+
+type errReader struct{ err error }
+
+func (r errReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
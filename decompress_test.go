@@ -0,0 +1,48 @@
+package fastcdc
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+)
+
+func TestTransparentDecompression(t *testing.T) {
+	data := make([]byte, 512*kiB)
+	fillLCG(data, 16)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(data)
+	zw.Close()
+	expected := Rechunk(data, DefaultParams)
+
+	for _, tc := range []struct {
+		input    []byte
+		encoding string
+	}{
+		{gz.Bytes(), EncodingGzip},
+		{data, EncodingNone},
+	} {
+		chunks := chunkAll(t, tc.input, WithTransparentDecompression())
+		if len(chunks) != len(expected) {
+			t.Fatalf("%q: expected %d chunks, got %d", tc.encoding, len(expected), len(chunks))
+		}
+		for i, c := range chunks {
+			if c.Offset != expected[i].Offset || !bytes.Equal(c.Data, expected[i].Data) {
+				t.Fatalf("%q: chunk %d mismatch at offset %d", tc.encoding, i, c.Offset)
+			}
+		}
+
+		chunker := NewChunker(bytes.NewReader(tc.input), WithTransparentDecompression())
+		chunker.Next()
+		if enc := chunker.Encoding(); enc != tc.encoding {
+			t.Errorf("expected encoding %q, got %q", tc.encoding, enc)
+		}
+	}
+
+	zstd := []byte{0x28, 0xb5, 0x2f, 0xfd, 0, 0, 0, 0}
+	_, err := NewChunker(bytes.NewReader(zstd), WithTransparentDecompression()).Next()
+	if !errors.Is(err, ErrEncoding) {
+		t.Errorf("expected ErrEncoding, got %v", err)
+	}
+}
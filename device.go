@@ -0,0 +1,105 @@
+package fastcdc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// WithZeroDetection makes the Chunker set Chunk.Zero for chunks of only
+// zero bytes, as read from trimmed or never written regions of disks, so
+// they can be recorded without being hashed or stored
+func WithZeroDetection() Option {
+	return func(c *Chunker) {
+		c.zeroDetection = true
+	}
+}
+
+var zeroBlock [4 * kiB]byte
+
+// isZero reports whether data is all zero bytes
+func isZero(data []byte) bool {
+	for len(data) > 0 {
+		n := min(len(data), len(zeroBlock))
+		if !bytes.Equal(data[:n], zeroBlock[:n]) {
+			return false
+		}
+		data = data[n:]
+	}
+	return true
+}
+
+// DeviceSize returns the size of a regular file or a block device. Block
+// devices report no size in their file info, so it is queried with an
+// ioctl on Linux and by seeking to the end elsewhere.
+func DeviceSize(f *os.File) (int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if fi.Mode().IsRegular() {
+		return fi.Size(), nil
+	}
+	if size, err := blockDeviceSize(f); err == nil {
+		return size, nil
+	}
+
+	pos, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	_, err = f.Seek(pos, io.SeekStart)
+	return size, err
+}
+
+// ChunkDevice chunks a block device or disk image file at path like
+// ChunkFile, reading exactly as many bytes as DeviceSize reports. It
+// returns io.ErrUnexpectedEOF if the device turns out to be shorter, unless
+// WithMaxChunks or WithMaxBytes stops chunking first.
+// Combine with WithZeroDetection to skip unused space cheaply, and with
+// WithDirectIO to keep a whole-disk read out of the page cache.
+func ChunkDevice(path string, fn func(c Chunk) error, opts ...Option) error {
+	c := NewChunker(nil, opts...)
+	f, direct, err := c.openFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size, err := DeviceSize(f)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = f
+	if direct {
+		r = newDirectReader(f, (len(c.buf)+directAlign-1)&^(directAlign-1))
+	}
+	c.Reset(io.LimitReader(r, size))
+	// Stop reading ahead before the file is closed
+	defer c.Reset(nil)
+
+	var n int64
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		n += int64(chunk.Length)
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+	limited := c.maxChunks > 0 && c.chunks >= c.maxChunks || c.maxBytes > 0 && n >= int64(c.maxBytes)
+	if n < size && !limited {
+		return fmt.Errorf("%s: read %d of %d bytes: %w", path, n, size, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
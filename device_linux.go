@@ -0,0 +1,21 @@
+//go:build linux
+
+package fastcdc
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const blkGetSize64 = 0x80081272 // BLKGETSIZE64 from linux/fs.h
+
+// blockDeviceSize returns the size of a block device in bytes
+func blockDeviceSize(f *os.File) (int64, error) {
+	var size uint64
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), blkGetSize64, uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0, errno
+	}
+	return int64(size), nil
+}
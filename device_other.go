@@ -0,0 +1,13 @@
+//go:build !linux
+
+package fastcdc
+
+import (
+	"errors"
+	"os"
+)
+
+// blockDeviceSize is not available, so DeviceSize seeks to the end instead
+func blockDeviceSize(f *os.File) (int64, error) {
+	return 0, errors.ErrUnsupported
+}
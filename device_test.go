@@ -0,0 +1,58 @@
+package fastcdc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChunkDevice(t *testing.T) {
+	// An image with unused space in the middle
+	data := make([]byte, 1*miB)
+	fillLCG(data[:256*kiB], 12)
+	fillLCG(data[768*kiB:], 13)
+	path := filepath.Join(t.TempDir(), "disk.img")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	size, err := DeviceSize(f)
+	f.Close()
+	if err != nil || size != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d (%v)", len(data), size, err)
+	}
+
+	var n, zero int
+	err = ChunkDevice(path, func(c Chunk) error {
+		if c.Zero != isZero(data[c.Offset:c.Offset+c.Length]) {
+			t.Fatalf("chunk at %d: expected zero %v", c.Offset, !c.Zero)
+		}
+		if c.Zero {
+			zero += c.Length
+		}
+		n += c.Length
+		return nil
+	}, WithZeroDetection(), WithDirectIO())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != len(data) || zero < 256*kiB {
+		t.Fatalf("expected %d bytes with most of %d zero, got %d with %d zero", len(data), 512*kiB, n, zero)
+	}
+
+	// Limits stop chunking early without the device seeming short
+	for name, opt := range map[string]Option{"chunks": WithMaxChunks(3), "bytes": WithMaxBytes(100 * kiB)} {
+		n := 0
+		err := ChunkDevice(path, func(c Chunk) error {
+			n += c.Length
+			return nil
+		}, opt, WithReadAhead(4))
+		if err != nil || n == 0 || n >= len(data) {
+			t.Errorf("%s: expected part of %d bytes, got %d (%v)", name, len(data), n, err)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package fastcdc
+
+import (
+	"io"
+	"os"
+	"unsafe"
+)
+
+// directAlign is the buffer alignment required for direct I/O
+const directAlign = 4096
+
+// WithDirectIO makes ChunkFile and ChunkFiles open files with O_DIRECT on
+// Linux, reading through aligned buffers and bypassing the page cache, so
+// chunking large amounts of data once does not evict more useful cached
+// data. Where direct I/O is not supported, such as on tmpfs or on other
+// platforms, files are read normally.
+func WithDirectIO() Option {
+	return func(c *Chunker) {
+		c.directIO = true
+	}
+}
+
+// openFile opens path for reading, with direct I/O if requested and
+// supported, and reports whether it is used
+func (c *Chunker) openFile(path string) (*os.File, bool, error) {
+	if c.directIO && oDirect != 0 {
+		if f, err := os.OpenFile(path, os.O_RDONLY|oDirect, 0); err == nil {
+			return f, true, nil
+		}
+	}
+	f, err := os.Open(path)
+	return f, false, err
+}
+
+// directReader reads whole aligned blocks from a file opened for direct
+// I/O, which rejects reads into unaligned buffers
+type directReader struct {
+	f    *os.File
+	buf  []byte
+	data []byte // Unread data in buf
+	err  error
+}
+
+func newDirectReader(f *os.File, size int) *directReader {
+	buf := make([]byte, size+directAlign)
+	skip := int(-uintptr(unsafe.Pointer(&buf[0])) & (directAlign - 1))
+	return &directReader{f: f, buf: buf[skip : skip+size]}
+}
+
+func (r *directReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		var n int
+		n, r.err = io.ReadFull(r.f, r.buf)
+		if r.err == io.ErrUnexpectedEOF {
+			r.err = io.EOF
+		}
+		r.data = r.buf[:n]
+	}
+
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
@@ -0,0 +1,7 @@
+//go:build linux
+
+package fastcdc
+
+import "syscall"
+
+const oDirect = syscall.O_DIRECT
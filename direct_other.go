@@ -0,0 +1,6 @@
+//go:build !linux
+
+package fastcdc
+
+// oDirect is zero where O_DIRECT is not available
+const oDirect = 0
@@ -0,0 +1,78 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+)
+
+func TestDirectReader(t *testing.T) {
+	data := make([]byte, 100*kiB+123)
+	fillLCG(data, 6)
+	path := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	r := newDirectReader(f, 8*kiB)
+	if addr := uintptr(unsafe.Pointer(&r.buf[0])); addr%directAlign != 0 || len(r.buf) != 8*kiB {
+		t.Fatalf("expected aligned buffer of %d bytes, got %d bytes at %#x", 8*kiB, len(r.buf), addr)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Errorf("expected file contents, got %d bytes", len(got))
+	}
+}
+
+func TestChunkFileDirectIO(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 1*miB+5)
+	fillLCG(data, 7)
+	path := filepath.Join(dir, "file")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewChunker(nil, WithDirectIO())
+	f, direct, err := c.openFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Close()
+	t.Logf("direct I/O used: %v", direct)
+
+	expected := Rechunk(data, DefaultParams)
+	for _, opts := range [][]Option{{WithDirectIO()}, {WithDirectIO(), WithIOUring()}} {
+		var offsets []int
+		err := ChunkFile(path, func(c Chunk) error {
+			if !bytes.Equal(c.Data, data[c.Offset:c.Offset+c.Length]) {
+				t.Fatalf("chunk data mismatch at offset %d", c.Offset)
+			}
+			offsets = append(offsets, c.Offset)
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(offsets) != len(expected) {
+			t.Fatalf("expected %d chunks, got %d", len(expected), len(offsets))
+		}
+		for i, offset := range offsets {
+			if offset != expected[i].Offset {
+				t.Fatalf("expected offset %d at index %d, got %d", expected[i].Offset, i, offset)
+			}
+		}
+	}
+}
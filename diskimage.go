@@ -0,0 +1,63 @@
+package fastcdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrUnknownImage is returned when input is not a supported disk image
+var ErrUnknownImage = errors.New("fastcdc: unknown disk image format")
+
+// Only the first sector is needed to find the cluster size
+const diskImageHeaderSize = 512
+
+var (
+	qcow2Magic = []byte("QFI\xfb")
+	vmdkMagic  = []byte("KDMV")
+)
+
+// DiskImageClusterSize returns the allocation unit size of a qcow2 image
+// or a VMDK sparse extent from its header, cluster size for the former and
+// grain size for the latter
+func DiskImageClusterSize(header []byte) (int, error) {
+	switch {
+	case len(header) >= 24 && bytes.HasPrefix(header, qcow2Magic):
+		clusterBits := binary.BigEndian.Uint32(header[20:24])
+		if clusterBits < 9 || clusterBits > 21 {
+			return 0, ErrUnknownImage
+		}
+		return 1 << clusterBits, nil
+
+	case len(header) >= 28 && bytes.HasPrefix(header, vmdkMagic):
+		// Grain size is given in 512-byte sectors
+		grain := binary.LittleEndian.Uint64(header[20:28])
+		if grain < 8 || grain > 1<<12 || grain&(grain-1) != 0 {
+			return 0, ErrUnknownImage
+		}
+		return int(grain) * 512, nil
+	}
+	return 0, ErrUnknownImage
+}
+
+// NewDiskImageChunker reads the header of a qcow2 or VMDK image from reader
+// and returns a Chunker that only cuts at cluster boundaries of the image.
+// Chunk sizes are scaled from the cluster size.
+func NewDiskImageChunker(reader io.Reader, opts ...Option) (*Chunker, error) {
+	header := make([]byte, diskImageHeaderSize)
+	n, err := io.ReadFull(reader, header)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	header = header[:n]
+
+	cluster, err := DiskImageClusterSize(header)
+	if err != nil {
+		return nil, err
+	}
+
+	reader = io.MultiReader(bytes.NewReader(header), reader)
+	opts = append([]Option{WithAlignment(cluster)}, opts...)
+	return NewChunkerWithParams(reader, cluster, 4*cluster, 16*cluster, opts...), nil
+}
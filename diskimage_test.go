@@ -0,0 +1,80 @@
+package fastcdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+func qcow2Data(clusterBits uint32, size int) []byte {
+	data := make([]byte, size)
+	fillLCG(data, 42)
+	copy(data, qcow2Magic)
+	binary.BigEndian.PutUint32(data[20:24], clusterBits)
+	return data
+}
+
+func vmdkData(grain uint64, size int) []byte {
+	data := make([]byte, size)
+	fillLCG(data, 42)
+	copy(data, vmdkMagic)
+	binary.LittleEndian.PutUint64(data[20:28], grain)
+	return data
+}
+
+func TestDiskImageChunker(t *testing.T) {
+	images := map[string][]byte{
+		"qcow2": qcow2Data(12, 4*miB+100),
+		"vmdk":  vmdkData(8, 4*miB+100),
+	}
+
+	for name, data := range images {
+		chunker, err := NewDiskImageChunker(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		total := 0
+		for {
+			chunk, err := chunker.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("%s: error getting next chunk: %v", name, err)
+			}
+
+			end := chunk.Offset + len(chunk.Data)
+			if end != len(data) && end%4096 != 0 {
+				t.Errorf("%s: chunk ending at %d is not cluster aligned", name, end)
+			}
+			total += len(chunk.Data)
+		}
+		if total != len(data) {
+			t.Errorf("%s: expected %d bytes in chunks, got %d", name, len(data), total)
+		}
+	}
+}
+
+func TestDiskImageClusterSize(t *testing.T) {
+	tests := []struct {
+		header []byte
+		size   int
+		err    error
+	}{
+		{qcow2Data(16, 512), 64 * kiB, nil},
+		{qcow2Data(30, 512), 0, ErrUnknownImage},
+		{vmdkData(128, 512), 64 * kiB, nil},
+		{vmdkData(100, 512), 0, ErrUnknownImage},
+		{vmdkData(128, 512)[:20], 0, ErrUnknownImage},
+		{make([]byte, 512), 0, ErrUnknownImage},
+	}
+
+	for i, test := range tests {
+		size, err := DiskImageClusterSize(test.header)
+		if size != test.size || err != test.err {
+			t.Errorf("test %d: expected %d, %v, got %d, %v", i, test.size, test.err, size, err)
+		}
+	}
+}
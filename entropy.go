@@ -0,0 +1,57 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"math"
+)
+
+// DefaultEntropyThreshold is a good threshold in bits per byte for telling
+// compressed or encrypted data apart from data that dedupes well
+const DefaultEntropyThreshold = 7.5
+
+// Entropy returns the Shannon entropy of data in bits per byte, from 0 for
+// constant data to 8 for uniformly random data. Content-defined chunking
+// is of little use on compressed or encrypted data, which scores near 8.
+func Entropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	e := 0.0
+	n := float64(len(data))
+	for _, c := range counts {
+		if c > 0 {
+			p := float64(c) / n
+			e -= p * math.Log2(p)
+		}
+	}
+	return e
+}
+
+// WithEntropyThreshold makes the Chunker compute the entropy of each chunk
+// and set Chunk.HighEntropy when it exceeds threshold bits per byte
+func WithEntropyThreshold(threshold float64) Option {
+	return func(c *Chunker) {
+		c.entropyThreshold = threshold
+	}
+}
+
+// ProbeEntropy reads up to n bytes from r and returns their entropy along
+// with a reader that yields the full stream, including the probed bytes.
+// This lets pipelines decide between CDC, fixed-size chunking or skipping
+// dedup before creating a Chunker.
+func ProbeEntropy(r io.Reader, n int) (float64, io.Reader, error) {
+	head := make([]byte, n)
+	read, err := io.ReadFull(r, head)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil
+	}
+	head = head[:read]
+	return Entropy(head), io.MultiReader(bytes.NewReader(head), r), err
+}
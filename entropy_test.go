@@ -0,0 +1,81 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestEntropy(t *testing.T) {
+	if e := Entropy(nil); e != 0 {
+		t.Errorf("expected 0 for empty data, got %f", e)
+	}
+	if e := Entropy(bytes.Repeat([]byte{'a'}, 100)); e != 0 {
+		t.Errorf("expected 0 for constant data, got %f", e)
+	}
+
+	uniform := make([]byte, 256*16)
+	for i := range uniform {
+		uniform[i] = byte(i)
+	}
+	if e := Entropy(uniform); math.Abs(e-8) > 1e-9 {
+		t.Errorf("expected 8 for uniform data, got %f", e)
+	}
+}
+
+func TestEntropyThreshold(t *testing.T) {
+	// Random half followed by text-like half
+	data := make([]byte, 512*kiB)
+	fillLCG(data[:256*kiB], 42)
+	text := []byte("the quick brown fox jumps over the lazy dog ")
+	for i := 256 * kiB; i < len(data); i++ {
+		data[i] = text[i%len(text)]
+	}
+
+	chunker := NewChunker(bytes.NewReader(data), WithEntropyThreshold(DefaultEntropyThreshold))
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+
+		end := chunk.Offset + len(chunk.Data)
+		if end <= 256*kiB && !chunk.HighEntropy {
+			t.Errorf("expected random chunk at %d to be flagged", chunk.Offset)
+		}
+		if chunk.Offset >= 256*kiB && chunk.HighEntropy {
+			t.Errorf("expected text chunk at %d not to be flagged", chunk.Offset)
+		}
+	}
+}
+
+func TestProbeEntropy(t *testing.T) {
+	data := make([]byte, 64*kiB)
+	fillLCG(data, 1)
+
+	e, r, err := ProbeEntropy(bytes.NewReader(data), 8*kiB)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if e < DefaultEntropyThreshold {
+		t.Errorf("expected random data above threshold, got %f", e)
+	}
+
+	// The returned reader must replay the probed bytes
+	all, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(all, data) {
+		t.Errorf("probed reader does not yield the original stream")
+	}
+
+	// Short inputs are not an error
+	if _, _, err := ProbeEntropy(bytes.NewReader(data[:10]), 8*kiB); err != nil {
+		t.Errorf("unexpected error for short input: %v", err)
+	}
+}
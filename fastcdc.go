@@ -2,14 +2,17 @@ package fastcdc
 
 import (
 	"io"
+	"sync"
 )
 
 type Chunker struct {
 	reader io.Reader
 	eof    bool // Whether we've hit EOF
+	done   bool // Whether the last chunk has been returned
 
 	buf       []byte
 	bufOffset int // Offset of buffer start in reader
+	base      int // Offset of the reader in a larger object, added to chunk offsets
 	pos       int // Current position in buffer
 	available int // Number of bytes available in buffer
 
@@ -19,11 +22,59 @@ type Chunker struct {
 
 	maskS uint64
 	maskL uint64
+	gear  *[256]uint64 // Gear table, G if nil
+
+	twoByte bool // Scan two bytes per iteration
+
+	entropyThreshold float64 // Flag chunks above this, 0 to disable
+	zeroDetection    bool    // Flag chunks of only zero bytes
+
+	hashPool *sync.Pool // Hashers for Chunk.Sum, nil to disable
+	hasher   *hasher    // Hasher taken from hashPool for the current stream
+
+	ownership Ownership // Whether chunks borrow buf or copy their data
+
+	policies []BoundaryPolicy
+
+	chunks    int // Number of chunks returned
+	maxChunks int // Stop after this many chunks, 0 for no limit
+	maxBytes  int // Stop after this many bytes, 0 for no limit
+
+	ioURing    bool                     // Read files through io_uring in ChunkFile and ChunkFiles
+	filePolicy FilePolicy               // Params for each file in ChunkFile and ChunkFiles
+	fileResult func(string, FileResult) // Result of each file in ChunkFile and ChunkFiles
+	directIO   bool                     // Open files with O_DIRECT in ChunkFile and ChunkFiles
+	bufSize    int                      // Size of buf, 0 for twice maxSize
+
+	decompress bool              // Detect and decompress gzip input
+	dec        *decompressReader // Decompressor of the current stream
+
+	readAhead      int        // Blocks to read ahead in the background, 0 for none
+	readAheadLimit int        // Bytes to adaptively read ahead, 0 for fixed
+	ra             *readAhead // Background reader of the current stream
 }
 
 type Chunk struct {
 	Offset int
-	Data   []byte
+	Length int
+	Data   []byte // Nil for offsets-only chunkers
+
+	HighEntropy bool   // Set if WithEntropyThreshold is used and exceeded
+	Sum         []byte // Hash of Data if WithHash is used, reused like Data
+	Zero        bool   // Set if WithZeroDetection is used and Data is all zeros
+
+	Reason CutReason // Why the chunk ends where it does
+	Final  bool      // Whether this is the last chunk
+}
+
+// Option configures optional Chunker behavior
+type Option func(*Chunker)
+
+// Params holds the minimum, average and maximum chunk sizes
+type Params struct {
+	MinSize int `json:"min_size"`
+	AvgSize int `json:"avg_size"`
+	MaxSize int `json:"max_size"`
 }
 
 const (
@@ -31,23 +82,114 @@ const (
 	miB = 1024 * kiB
 )
 
-func NewChunker(reader io.Reader) *Chunker {
-	return NewChunkerWithParams(reader, 2*kiB, 8*kiB, 32*kiB)
+// DefaultParams are the chunk sizes used by NewChunker
+var DefaultParams = Params{MinSize: 2 * kiB, AvgSize: 8 * kiB, MaxSize: 32 * kiB}
+
+func NewChunker(reader io.Reader, opts ...Option) *Chunker {
+	p := DefaultParams
+	return NewChunkerWithParams(reader, p.MinSize, p.AvgSize, p.MaxSize, opts...)
+}
+
+func NewChunkerWithParams(reader io.Reader, minSize, avgSize, maxSize int, opts ...Option) *Chunker {
+	c := &Chunker{}
+	c.setParams(Params{MinSize: minSize, AvgSize: avgSize, MaxSize: maxSize})
+	for _, opt := range opts {
+		opt(c)
+	}
+	size := c.bufSize
+	if size == 0 {
+		size = c.maxSize * 2
+	}
+	c.buf = make([]byte, max(size, c.maxSize+1))
+	c.setReader(reader)
+	return c
+}
+
+// WithParams overrides the chunk sizes given to the constructor
+func WithParams(p Params) Option {
+	return func(c *Chunker) {
+		c.setParams(p)
+	}
+}
+
+// WithBufferSize sets the size of the read buffer, by default twice the
+// max size. It is raised to the minimum of max size plus one, which suits
+// devices with little memory at the cost of smaller reads and more
+// copying.
+func WithBufferSize(n int) Option {
+	return func(c *Chunker) {
+		c.bufSize = n
+	}
+}
+
+// Reset makes the Chunker start over with a new reader, reusing its buffer
+// and options. It is the way to reuse a Chunker that is done.
+func (c *Chunker) Reset(reader io.Reader) {
+	c.eof = false
+	c.bufOffset = 0
+	c.base = 0
+	c.pos = 0
+	c.available = 0
+	c.chunks = 0
+	c.putHasher()
+	c.setReader(reader)
+}
+
+func (c *Chunker) setReader(reader io.Reader) {
+	if c.ra != nil {
+		c.ra.close()
+		c.ra = nil
+	}
+	c.reader = reader
+	c.done = reader == nil
+
+	c.dec = nil
+	if c.decompress && reader != nil {
+		c.dec = &decompressReader{r: reader}
+		c.reader = c.dec
+	}
+
+	// Cut points within the limit never depend on data beyond maxSize more
+	if c.maxBytes > 0 {
+		c.reader = io.LimitReader(c.reader, int64(c.maxBytes+c.maxSize))
+	}
+
+	if c.readAhead > 0 && reader != nil {
+		c.ra = newReadAhead(c.reader, c.readAhead, c.readAheadLimit/c.maxSize, c.maxSize)
+		c.reader = c.ra
+	}
 }
 
-func NewChunkerWithParams(reader io.Reader, minSize, avgSize, maxSize int) *Chunker {
-	b := bits(avgSize) - 1
-	maskS := spread(b + 2)
-	maskL := spread(b - 2)
-	return &Chunker{
-		reader:  reader,
-		buf:     make([]byte, maxSize*2),
-		minSize: minSize,
-		avgSize: avgSize,
-		maxSize: maxSize,
-		maskS:   maskS,
-		maskL:   maskL,
+// setParams sets chunk sizes and derives the masks from the average size
+func (c *Chunker) setParams(p Params) {
+	b := bits(p.AvgSize) - 1
+	c.minSize = p.MinSize
+	c.avgSize = p.AvgSize
+	c.maxSize = p.MaxSize
+	c.maskS = spread(b + Normalization)
+	c.maskL = spread(b - Normalization)
+}
+
+// Rechunk splits an existing chunk into smaller chunks using params p. This
+// allows hierarchical schemes where only hot regions get finer granularity.
+// Chunk data are slices of the input and offsets are relative to its start.
+func Rechunk(chunk []byte, p Params) []Chunk {
+	var c Chunker
+	c.setParams(p)
+
+	var chunks []Chunk
+	for pos := 0; pos < len(chunk); {
+		cutPoint, reason := c.findCutPoint(chunk[pos:])
+		chunks = append(chunks, Chunk{
+			Offset: pos,
+			Length: cutPoint,
+			Data:   chunk[pos : pos+cutPoint],
+			Reason: reason,
+			Final:  pos+cutPoint == len(chunk),
+		})
+		pos += cutPoint
 	}
+	return chunks
 }
 
 // fillBuffer attempts to fill the buffer with data from the reader
@@ -74,11 +216,20 @@ func (c *Chunker) fillBuffer() error {
 }
 
 // Next returns the offset of next chunk boundary and io.EOF on last chunk.
-// Chunk data is a slice of the original data, so it is invalidated on the
-// next call to Next().
+// By default chunk data is a slice of the internal buffer, so it is
+// invalidated on the next call to Next(). See WithOwnership and Clone.
+//
+// Once the chunk with Final set has been returned, or there is no reader,
+// Next keeps returning io.EOF without reading until Reset. Read errors are
+// returned as is, and a later call retries the read.
 func (c *Chunker) Next() (Chunk, error) {
-	// If we don't have enough data in the buffer to potentially find a cut point
-	if !c.eof && c.available-c.pos < c.maxSize {
+	if c.done || c.maxChunks > 0 && c.chunks >= c.maxChunks || c.maxBytes > 0 && c.bufOffset+c.pos >= c.maxBytes {
+		return c.finish()
+	}
+
+	// If we don't have enough data in the buffer to potentially find a cut
+	// point, or to know whether the next chunk is the final one
+	if !c.eof && c.available-c.pos <= c.maxSize {
 		// Move any remaining data to start of buffer
 		if c.pos > 0 {
 			copy(c.buf, c.buf[c.pos:c.available])
@@ -97,59 +248,149 @@ func (c *Chunker) Next() (Chunk, error) {
 
 	// If we have no data left, we're done
 	if c.pos >= c.available {
-		return Chunk{}, io.EOF
+		return c.finish()
+	}
+
+	// Limit search to before the first forced boundary, if any
+	data := c.buf[c.pos:c.available]
+	offset := c.bufOffset + c.pos
+	forced := false
+	for _, p := range c.policies {
+		if hint := p.Hint(offset, data); hint > 0 && hint <= min(len(data), c.maxSize) {
+			data = data[:hint]
+			forced = true
+		}
 	}
 
 	// Find cut point -- can also be size of available data (if EOF)
-	cutPoint := c.findCutPoint(c.buf[c.pos:c.available])
+	cutPoint, reason := c.findCutPoint(data)
+	if forced && cutPoint == len(data) {
+		reason = CutPolicy
+	}
+
+	// Let policies move content-defined cut points, except at EOF
+	if c.pos+cutPoint < c.available && reason != CutPolicy {
+		data = data[:min(len(data), c.maxSize)]
+		adjusted := cutPoint
+		for _, p := range c.policies {
+			adjusted = min(max(p.Adjust(offset, data, adjusted), 1), len(data))
+		}
+		if adjusted != cutPoint {
+			cutPoint, reason = adjusted, CutPolicy
+		}
+	}
 
 	// Create a chunk
 	chunk := Chunk{
-		Offset: c.bufOffset + c.pos,
+		Offset: c.base + offset,
+		Length: cutPoint,
 		Data:   c.buf[c.pos : c.pos+cutPoint],
 	}
+	if c.entropyThreshold > 0 {
+		chunk.HighEntropy = Entropy(chunk.Data) > c.entropyThreshold
+	}
+	if c.zeroDetection {
+		chunk.Zero = isZero(chunk.Data)
+	}
+	if c.hashPool != nil {
+		chunk.Sum = c.hashSum(chunk.Data)
+	}
 
 	// Update position, next call to Next() will start at this point
 	c.pos += cutPoint
+	c.chunks++
+
+	chunk.Reason = reason
+	chunk.Final = c.eof && c.pos == c.available ||
+		c.maxChunks > 0 && c.chunks >= c.maxChunks ||
+		c.maxBytes > 0 && c.bufOffset+c.pos >= c.maxBytes
+
+	c.done = chunk.Final
+	if c.ownership == Copy {
+		chunk = chunk.Clone()
+	}
 
 	// Return offset of cut point
 	return chunk, nil
 }
 
+// finish marks the stream done and returns io.EOF
+func (c *Chunker) finish() (Chunk, error) {
+	c.done = true
+	c.putHasher()
+	return Chunk{}, io.EOF
+}
+
+// Done reports whether the last chunk has been returned, after which Next
+// returns io.EOF
+func (c *Chunker) Done() bool {
+	return c.done
+}
+
 // findCutPoint implements the FastCDC cut point selection algorithm
-func (c *Chunker) findCutPoint(data []byte) int {
+func (c *Chunker) findCutPoint(data []byte) (int, CutReason) {
 	//fmt.Printf("findCutPoint(%d), %d\n", len(data), data[0])
 
 	if len(data) <= c.minSize {
 		//fmt.Printf("data length %d <= minSize %d\n", len(data), c.minSize)
-		return len(data)
+		return len(data), CutEOF
 	}
 
-	// Initialize fingerprint
-	fp := uint64(0)
-	i := c.minSize
+	i, reason, _ := c.scan(data, c.minSize, 0)
+	if reason == CutEOF {
+		return len(data), reason
+	}
+	return i, reason
+}
+
+// scan searches data for a cut point starting from position i with
+// fingerprint fp, which allows continuing the search when more data is
+// available. It returns the cut point, or with CutEOF the position to
+// continue from if none was found, along with the fingerprint at that
+// point.
+func (c *Chunker) scan(data []byte, i int, fp uint64) (int, CutReason, uint64) {
+	if c.twoByte {
+		return c.scan2(data, i, fp)
+	}
+
+	gear := c.gear
+	if gear == nil {
+		gear = &G
+	}
+
+	// Ranging over explicit sub-slices lets the compiler drop bounds checks
+	maskS, maskL := c.maskS, c.maskL
 
 	// Search using the "small" mask between min and avg size
-	for ; i < c.avgSize && i < len(data); i++ {
-		fp = (fp << 1) + G[data[i]]
-		if (fp & c.maskS) == 0 {
-			//fmt.Printf("maskS cut point at %d (between %d and %d)\n", i, c.minSize, c.avgSize)
-			return i
+	if end := min(c.avgSize, len(data)); i < end {
+		for j, b := range data[i:end] {
+			fp = (fp << 1) + gear[b]
+			if (fp & maskS) == 0 {
+				//fmt.Printf("maskS cut point at %d (between %d and %d)\n", i+j, c.minSize, c.avgSize)
+				return i + j, CutMaskS, fp
+			}
 		}
+		i = end
 	}
 
 	// Search using the "large" mask if we haven't found a cut point
-	for ; i < c.maxSize && i < len(data); i++ {
-		fp = (fp << 1) + G[data[i]]
-		if (fp & c.maskL) == 0 {
-			//fmt.Printf("maskL cut point at %d (between %d and %d)\n", i, c.avgSize, c.maxSize)
-			return i
+	if end := min(c.maxSize, len(data)); i < end {
+		for j, b := range data[i:end] {
+			fp = (fp << 1) + gear[b]
+			if (fp & maskL) == 0 {
+				//fmt.Printf("maskL cut point at %d (between %d and %d)\n", i+j, c.avgSize, c.maxSize)
+				return i + j, CutMaskL, fp
+			}
 		}
+		i = end
 	}
 
 	//fmt.Printf("no cut point found, returning %d\n", i)
 	// If we haven't found a cut point, return max size or end of data
-	return i
+	if i == c.maxSize {
+		return i, CutMaxSize, fp
+	}
+	return i, CutEOF, fp
 }
 
 // bits returns the number of bits needed to represent n
@@ -2,21 +2,15 @@ package fastcdc
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"io"
 	"testing"
-)
+	"testing/iotest"
 
-func fillLCG(data []byte, seed uint32) {
-	const m = 1 << 31
-	const a = 1103515245
-	const c = 12345
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
 
-	for i := range data {
-		seed = (a*seed + c) % m
-		// Will repeat after 16 MB (1 << 24)
-		data[i] = byte((seed >> 16) & 0xFF)
-	}
-}
+var fillLCG = datagen.FillLCG
 
 func TestChunker(t *testing.T) {
 	// Generate 1 MB of pseudorandom data
@@ -64,3 +58,152 @@ func TestChunker(t *testing.T) {
 		}
 	}
 }
+
+func TestRechunk(t *testing.T) {
+	data := make([]byte, 256*kiB)
+	fillLCG(data, 7)
+
+	// Rechunking with the same params must match streaming boundaries
+	p := Params{MinSize: 2 * kiB, AvgSize: 8 * kiB, MaxSize: 32 * kiB}
+	chunker := NewChunkerWithParams(bytes.NewReader(data), p.MinSize, p.AvgSize, p.MaxSize)
+
+	var expected []int
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		expected = append(expected, chunk.Offset)
+	}
+
+	chunks := Rechunk(data, p)
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+
+	total := 0
+	for i, chunk := range chunks {
+		if chunk.Offset != expected[i] {
+			t.Errorf("expected offset %d at index %d, got %d", expected[i], i, chunk.Offset)
+		}
+		if !bytes.Equal(chunk.Data, data[chunk.Offset:chunk.Offset+len(chunk.Data)]) {
+			t.Fatalf("chunk data mismatch at offset %d", chunk.Offset)
+		}
+		total += len(chunk.Data)
+	}
+	if total != len(data) {
+		t.Fatalf("expected %d bytes in chunks, got %d", len(data), total)
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	data := make([]byte, 64*kiB)
+	fillLCG(data, 9)
+	p := Params{MinSize: 64, AvgSize: 256, MaxSize: 1 * kiB}
+	expected := Rechunk(data, p)
+
+	for _, tc := range []struct{ size, expected int }{
+		{0, 2 * kiB},
+		{1, 1*kiB + 1},
+		{1500, 1500},
+	} {
+		chunker := NewChunker(iotest.OneByteReader(bytes.NewReader(data)), WithParams(p), WithBufferSize(tc.size))
+		if len(chunker.buf) != tc.expected {
+			t.Errorf("size %d: expected buffer of %d bytes, got %d", tc.size, tc.expected, len(chunker.buf))
+		}
+
+		for i := 0; ; i++ {
+			chunk, err := chunker.Next()
+			if err == io.EOF {
+				if i != len(expected) {
+					t.Fatalf("size %d: expected %d chunks, got %d", tc.size, len(expected), i)
+				}
+				break
+			}
+			if err != nil {
+				t.Fatalf("size %d: unexpected error: %v", tc.size, err)
+			}
+			e := expected[i]
+			if chunk.Offset != e.Offset || !bytes.Equal(chunk.Data, e.Data) || chunk.Final != e.Final {
+				t.Fatalf("size %d: chunk %d mismatch at offset %d", tc.size, i, chunk.Offset)
+			}
+		}
+	}
+}
+
+func TestNextAfterEOF(t *testing.T) {
+	data := make([]byte, 64*kiB)
+	fillLCG(data, 6)
+
+	chunker := NewChunker(nil)
+	if !chunker.Done() {
+		t.Fatalf("expected chunker without reader to be done")
+	}
+	if _, err := chunker.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF without reader, got %v", err)
+	}
+
+	for range 2 {
+		chunker.Reset(bytes.NewReader(data))
+		n := 0
+		for !chunker.Done() {
+			chunk, err := chunker.Next()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			n += chunk.Length
+		}
+		if n != len(data) {
+			t.Fatalf("expected %d bytes before done, got %d", len(data), n)
+		}
+		for range 3 {
+			if _, err := chunker.Next(); err != io.EOF {
+				t.Fatalf("expected io.EOF after done, got %v", err)
+			}
+		}
+	}
+}
+
+func benchmarkScan(b *testing.B, opts ...Option) {
+	data := make([]byte, 8*miB)
+	fillLCG(data, 1)
+	c := NewChunker(nil, opts...)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for range b.N {
+		for pos := 0; pos < len(data); {
+			cutPoint, _ := c.findCutPoint(data[pos:])
+			pos += cutPoint
+		}
+	}
+}
+
+func BenchmarkScan(b *testing.B)        { benchmarkScan(b) }
+func BenchmarkScanTwoByte(b *testing.B) { benchmarkScan(b, WithTwoByteScan()) }
+
+func benchmarkNext(b *testing.B, opts ...Option) {
+	data := make([]byte, 8*miB)
+	fillLCG(data, 1)
+	r := bytes.NewReader(data)
+	c := NewChunker(r, opts...)
+
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		r.Reset(data)
+		c.Reset(r)
+		for {
+			if _, err := c.Next(); err == io.EOF {
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkNext(b *testing.B)       { benchmarkNext(b) }
+func BenchmarkNextSHA256(b *testing.B) { benchmarkNext(b, WithHash(sha256.New)) }
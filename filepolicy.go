@@ -0,0 +1,68 @@
+package fastcdc
+
+import (
+	"fmt"
+	"os"
+	"slices"
+)
+
+// FilePolicy chooses the params to chunk the file at path with from its
+// path and size, or returns false to skip the file. Equal minimum, average
+// and maximum sizes give fixed-size chunks, which suit data that never
+// dedupes at shifted offsets, such as compressed media.
+type FilePolicy func(path string, size int64) (Params, bool)
+
+// WithFilePolicy makes ChunkFile and ChunkFiles chunk each file with the
+// params chosen by policy instead of the Chunker's, or skip it. Use
+// WithFileResult to record the params chosen for each file.
+func WithFilePolicy(policy FilePolicy) Option {
+	return func(c *Chunker) {
+		c.filePolicy = policy
+	}
+}
+
+// FileResult tells how ChunkFile or ChunkFiles chunked a file, for
+// recording such as in its manifest header
+type FileResult struct {
+	Params   Params // Params of the chunks, as chosen by the file policy
+	Encoding string // Encoding detected with WithTransparentDecompression
+}
+
+// WithFileResult makes ChunkFile and ChunkFiles call fn with the result of
+// each file after its last chunk. Files skipped by the file policy or
+// stopped by an error are not reported. Like the chunk callback of
+// ChunkFiles, fn is called concurrently for different files.
+func WithFileResult(fn func(path string, r FileResult)) Option {
+	return func(c *Chunker) {
+		c.fileResult = fn
+	}
+}
+
+// chunkerFor returns the Chunker for the file at path, or nil if the file
+// policy of c skips it. Chunkers with other params are created with opts
+// and kept in others for reuse.
+func (c *Chunker) chunkerFor(path string, others map[Params]*Chunker, opts []Option) (*Chunker, error) {
+	if c.filePolicy == nil {
+		return c, nil
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := c.filePolicy(path, fi.Size())
+	switch {
+	case !ok:
+		return nil, nil
+	case p == c.Params():
+		return c, nil
+	}
+	if err := p.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if other, ok := others[p]; ok {
+		return other, nil
+	}
+	other := NewChunker(nil, append(slices.Clip(opts), WithParams(p))...)
+	others[p] = other
+	return other, nil
+}
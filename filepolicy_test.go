@@ -0,0 +1,104 @@
+package fastcdc
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFilePolicy(t *testing.T) {
+	dir := t.TempDir()
+	data := make([]byte, 200*kiB)
+	fillLCG(data, 1)
+	var paths []string
+	for _, name := range []string{"a.png", "b.txt", "c.tmp", "d.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	fixed := Params{MinSize: 16 * kiB, AvgSize: 16 * kiB, MaxSize: 16 * kiB}
+	small := Params{MinSize: 512, AvgSize: 2 * kiB, MaxSize: 8 * kiB}
+	policy := func(path string, size int64) (Params, bool) {
+		if size != int64(len(data)) {
+			t.Errorf("%s: expected size %d, got %d", path, len(data), size)
+		}
+		switch filepath.Ext(path) {
+		case ".png":
+			return fixed, true
+		case ".tmp":
+			return Params{}, false
+		}
+		return small, true
+	}
+
+	var mu sync.Mutex
+	lengths := map[string][]int{}
+	results := map[string]FileResult{}
+	err := ChunkFiles(paths, 2, func(path string, c Chunk) error {
+		mu.Lock()
+		lengths[filepath.Base(path)] = append(lengths[filepath.Base(path)], c.Length)
+		mu.Unlock()
+		return nil
+	}, WithFilePolicy(policy), WithFileResult(func(path string, r FileResult) {
+		mu.Lock()
+		results[filepath.Base(path)] = r
+		mu.Unlock()
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The params chosen for each file are reported
+	expected := map[string]FileResult{"a.png": {Params: fixed}, "b.txt": {Params: small}, "d.txt": {Params: small}}
+	if !maps.Equal(results, expected) {
+		t.Errorf("expected results %v, got %v", expected, results)
+	}
+
+	if _, ok := lengths["c.tmp"]; ok {
+		t.Fatalf("expected c.tmp to be skipped")
+	}
+	for i, n := range lengths["a.png"] {
+		if n != fixed.AvgSize && i != len(lengths["a.png"])-1 {
+			t.Fatalf("expected fixed-size chunks of a.png, got %v", lengths["a.png"])
+		}
+	}
+	for name, ns := range lengths {
+		if strings.HasSuffix(name, ".txt") && len(ns) < len(data)/small.MaxSize {
+			t.Fatalf("expected small chunks of %s, got %d", name, len(ns))
+		}
+	}
+
+	// ChunkFile follows the policy too
+	var n int
+	err = ChunkFile(paths[0], func(c Chunk) error {
+		n++
+		return nil
+	}, WithFilePolicy(policy))
+	if err != nil || n != len(lengths["a.png"]) {
+		t.Fatalf("expected %d chunks, got %d, %v", len(lengths["a.png"]), n, err)
+	}
+	// Invalid params from the policy are an error for the file
+	bad := func(path string, size int64) (Params, bool) {
+		if filepath.Ext(path) == ".png" {
+			return Params{}, true
+		}
+		return small, true
+	}
+	n = 0
+	err = ChunkFiles(paths, 2, func(path string, c Chunk) error {
+		mu.Lock()
+		n++
+		mu.Unlock()
+		return nil
+	}, WithFilePolicy(bad))
+	if !errors.Is(err, ErrInvalidParams) || !strings.Contains(err.Error(), "a.png") || n == 0 {
+		t.Errorf("expected ErrInvalidParams for a.png only, got %d chunks, %v", n, err)
+	}
+}
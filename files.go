@@ -0,0 +1,139 @@
+package fastcdc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// ChunkFile chunks the file at path and calls fn for each chunk. Chunk
+// data is only valid during the call.
+func ChunkFile(path string, fn func(c Chunk) error, opts ...Option) error {
+	c := NewChunker(nil, opts...)
+	r := c.newRing()
+	if r != nil {
+		defer r.close()
+	}
+	c, err := c.chunkerFor(path, map[Params]*Chunker{}, opts)
+	if err != nil || c == nil {
+		return err
+	}
+	return chunkFile(c, r, path, fn)
+}
+
+// WithIOUring makes ChunkFile and ChunkFiles read files through io_uring
+// with registered buffers, reading ahead of the chunker. Each worker sets
+// up its own ring. Where io_uring is unavailable, such as on other
+// platforms or in restricted containers, files are read normally.
+func WithIOUring() Option {
+	return func(c *Chunker) {
+		c.ioURing = true
+	}
+}
+
+// newRing returns an io_uring for reading files if requested and available
+func (c *Chunker) newRing() *ring {
+	if !c.ioURing {
+		return nil
+	}
+	r, err := newRing()
+	if err != nil {
+		return nil
+	}
+	return r
+}
+
+// ChunkFiles chunks files using a pool of workers, each reusing a single
+// Chunker and its buffer. fn is called with the chunks of each file in
+// order, but for different files it is called concurrently. Chunk data is
+// only valid during the call. If workers is zero or less, runtime.NumCPU()
+// workers are used.
+//
+// An error from opening or reading a file, or returned by fn, stops the
+// processing of that file only. The errors of all files are joined and
+// returned once all files are done.
+func ChunkFiles(paths []string, workers int, fn func(path string, c Chunk) error, opts ...Option) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	jobs := make(chan int)
+	errs := make([]error, len(paths))
+
+	var wg sync.WaitGroup
+	for range min(workers, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c := NewChunker(nil, opts...)
+			r := c.newRing()
+			if r != nil {
+				defer r.close()
+			}
+			others := make(map[Params]*Chunker)
+			for i := range jobs {
+				path := paths[i]
+				fc, err := c.chunkerFor(path, others, opts)
+				if err == nil && fc != nil {
+					err = chunkFile(fc, r, path, func(chunk Chunk) error {
+						return fn(path, chunk)
+					})
+				}
+				if err != nil {
+					errs[i] = fmt.Errorf("%s: %w", path, err)
+				}
+			}
+		}()
+	}
+
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func chunkFile(c *Chunker, r *ring, path string, fn func(Chunk) error) (err error) {
+	f, direct, err := c.openFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch {
+	case r == nil && direct:
+		c.Reset(newDirectReader(f, (len(c.buf)+directAlign-1)&^(directAlign-1)))
+	case r == nil:
+		c.Reset(f)
+	default:
+		// Registered buffers are page aligned, as direct I/O requires
+		rc := r.reader(f)
+		defer func() {
+			if cerr := rc.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		c.Reset(rc)
+	}
+	// Stop reading ahead before the reader is closed
+	defer c.Reset(nil)
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			if c.fileResult != nil {
+				c.fileResult(path, FileResult{Params: c.Params(), Encoding: c.Encoding()})
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(chunk); err != nil {
+			return err
+		}
+	}
+}
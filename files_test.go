@@ -0,0 +1,94 @@
+package fastcdc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestChunkFiles(t *testing.T) {
+	dir := t.TempDir()
+	p := Params{MinSize: 1 * kiB, AvgSize: 4 * kiB, MaxSize: 16 * kiB}
+
+	files := map[string][]byte{}
+	var paths []string
+	for i := 0; i < 10; i++ {
+		data := make([]byte, i*50*kiB)
+		fillLCG(data, uint32(i))
+		path := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files[path] = data
+		paths = append(paths, path)
+	}
+
+	var mu sync.Mutex
+	offsets := map[string][]int{}
+	err := ChunkFiles(paths, 3, func(path string, c Chunk) error {
+		if !bytes.Equal(c.Data, files[path][c.Offset:c.Offset+c.Length]) {
+			return fmt.Errorf("chunk data mismatch at offset %d", c.Offset)
+		}
+		mu.Lock()
+		offsets[path] = append(offsets[path], c.Offset)
+		mu.Unlock()
+		return nil
+	}, WithParams(p))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for path, data := range files {
+		expected := Rechunk(data, p)
+		if len(offsets[path]) != len(expected) {
+			t.Fatalf("%s: expected %d chunks, got %d", path, len(expected), len(offsets[path]))
+		}
+		for i, offset := range offsets[path] {
+			if offset != expected[i].Offset {
+				t.Errorf("%s: expected offset %d at index %d, got %d", path, expected[i].Offset, i, offset)
+			}
+		}
+	}
+
+	// Errors are collected from all files
+	fnErr := errors.New("callback failed")
+	missing := filepath.Join(dir, "missing")
+	err = ChunkFiles(append(paths, missing), 0, func(path string, c Chunk) error {
+		if path == paths[5] {
+			return fnErr
+		}
+		return nil
+	})
+	if !errors.Is(err, fnErr) || !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected both errors, got %v", err)
+	}
+	if !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected error to name %s, got %v", missing, err)
+	}
+}
+
+func TestChunkerReset(t *testing.T) {
+	a := make([]byte, 100*kiB)
+	b := make([]byte, 200*kiB)
+	fillLCG(a, 1)
+	fillLCG(b, 2)
+
+	chunker := NewChunker(bytes.NewReader(a))
+	for _, data := range [][]byte{a, b} {
+		chunker.Reset(bytes.NewReader(data))
+		for i, expected := range Rechunk(data, DefaultParams) {
+			chunk, err := chunker.Next()
+			if err != nil {
+				t.Fatalf("error getting chunk %d: %v", i, err)
+			}
+			if chunk.Offset != expected.Offset || chunk.Length != expected.Length {
+				t.Errorf("expected chunk %d at %d, got %d", i, expected.Offset, chunk.Offset)
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package fastcdc
+
+import (
+	"fmt"
+	"io"
+)
+
+// Splitter is implemented by all chunkers in this package, so applications
+// can switch chunking strategy per stream without separate code paths
+type Splitter interface {
+	// Next returns the next chunk and io.EOF after the last one
+	Next() (Chunk, error)
+}
+
+var (
+	_ Splitter = (*Chunker)(nil)
+	_ Splitter = (*FixedChunker)(nil)
+)
+
+// FixedChunker splits a stream into chunks of constant size, with only the
+// last chunk possibly being shorter. It is useful as a fallback for data
+// that does not benefit from content-defined chunking, like media files.
+type FixedChunker struct {
+	reader    io.Reader
+	buf       []byte // Chunk size plus one byte to detect the final chunk
+	lookahead bool   // Whether the last byte of buf starts the next chunk
+	offset    int    // Offset of next chunk in reader
+	err       error  // Error for an invalid size
+}
+
+// NewFixedChunker returns a FixedChunker with chunks of size bytes. If size
+// is not positive, Next returns an error wrapping ErrInvalidParams.
+func NewFixedChunker(reader io.Reader, size int) *FixedChunker {
+	if size <= 0 {
+		return &FixedChunker{err: fmt.Errorf("%w: fixed size %d not positive", ErrInvalidParams, size)}
+	}
+	return &FixedChunker{
+		reader: reader,
+		buf:    make([]byte, size+1),
+	}
+}
+
+// Next returns the next chunk and io.EOF after the last one. As with
+// Chunker, chunk data is invalidated on the next call to Next().
+func (c *FixedChunker) Next() (Chunk, error) {
+	if c.err != nil {
+		return Chunk{}, c.err
+	}
+	size := len(c.buf) - 1
+
+	// Move the byte read ahead on the previous call to the start
+	start := 0
+	if c.lookahead {
+		c.buf[0] = c.buf[size]
+		start = 1
+	}
+
+	n, err := io.ReadFull(c.reader, c.buf[start:])
+	n += start
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return Chunk{}, err
+	}
+	if n == 0 {
+		return Chunk{}, io.EOF
+	}
+	c.lookahead = n > size
+
+	chunk := Chunk{
+		Offset: c.offset,
+		Length: min(n, size),
+		Data:   c.buf[:min(n, size)],
+		Reason: CutMaxSize,
+		Final:  !c.lookahead,
+	}
+	if n < size {
+		chunk.Reason = CutEOF
+	}
+	c.offset += chunk.Length
+	return chunk, nil
+}
@@ -0,0 +1,56 @@
+package fastcdc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFixedChunker(t *testing.T) {
+	data := make([]byte, 100*kiB+123)
+	fillLCG(data, 42)
+
+	var s Splitter = NewFixedChunker(bytes.NewReader(data), 8*kiB)
+
+	var offsets []int
+	for {
+		chunk, err := s.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		if !bytes.Equal(chunk.Data, data[chunk.Offset:chunk.Offset+len(chunk.Data)]) {
+			t.Fatalf("chunk data mismatch at offset %d", chunk.Offset)
+		}
+		offsets = append(offsets, chunk.Offset+len(chunk.Data))
+	}
+
+	if len(offsets) != 13 {
+		t.Fatalf("expected 13 chunks, got %d", len(offsets))
+	}
+	for i, offset := range offsets[:12] {
+		if offset != (i+1)*8*kiB {
+			t.Errorf("expected offset %d at index %d, got %d", (i+1)*8*kiB, i, offset)
+		}
+	}
+	if offsets[12] != len(data) {
+		t.Errorf("expected last offset %d, got %d", len(data), offsets[12])
+	}
+
+	// Further calls keep returning io.EOF
+	if _, err := s.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after last chunk, got %v", err)
+	}
+}
+
+func TestFixedChunkerSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		c := NewFixedChunker(bytes.NewReader([]byte("data")), size)
+		if _, err := c.Next(); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("size %d: expected ErrInvalidParams, got %v", size, err)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+// Package framed encodes a stream as frames of literal chunk data and
+// references back to earlier identical chunks, deduplicating it in a
+// single pass without a repository or a signature from the receiver. The
+// decoder only needs to keep a window of recent output, so streams of any
+// size can be piped through.
+package framed
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// magic starts an encoded stream, and changes with incompatible versions
+var magic = [4]byte{'F', 'C', 'F', '1'}
+
+// DefaultWindow is the window used by the CLI, which bounds how far back
+// references reach and how much output the decoder keeps
+const DefaultWindow = 64 << 20
+
+// Frames
+const (
+	frameEnd     = 0 // Varint size and SHA-256 of the stream
+	frameRepeat  = 1 // Varint distance back in the stream and length
+	frameLiteral = 2 // Varint length and the data
+)
+
+var (
+	// ErrFormat is wrapped by errors for malformed streams
+	ErrFormat = errors.New("framed: invalid stream format")
+
+	// ErrMismatch is returned when the decoded stream does not match the
+	// size and hash of the encoded one
+	ErrMismatch = errors.New("framed: stream does not match")
+)
+
+// Stats counts the bytes an encoded stream repeats from earlier in the
+// stream and sends as literals
+type Stats struct {
+	Repeated int64
+	Literal  int64
+}
+
+// Encode chunks r and writes it to w as frames, replacing chunks seen
+// within the last window bytes with references to them. References to
+// adjacent chunks are merged.
+func Encode(w io.Writer, r io.Reader, window int64, opts ...fastcdc.Option) (Stats, error) {
+	var stats Stats
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	put := func(frame byte, values ...uint64) error {
+		buf = append(buf[:0], frame)
+		for _, v := range values {
+			buf = binary.AppendUvarint(buf, v)
+		}
+		_, err := bw.Write(buf)
+		return err
+	}
+
+	bw.Write(magic[:])
+	bw.Write(binary.AppendUvarint(nil, uint64(window)))
+
+	type seen struct {
+		sum    [sha256.Size]byte
+		offset int64
+	}
+	offsets := make(map[[sha256.Size]byte]int64) // Latest offset of each chunk
+	var queue []seen                             // Chunks in offsets, oldest first
+
+	c := fastcdc.NewChunker(r, append([]fastcdc.Option{fastcdc.WithHash(sha256.New)}, opts...)...)
+	h := sha256.New()
+	var size int64
+	var repeatStart, repeatOffset, repeatLength int64 // Pending reference
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		h.Write(chunk.Data)
+		offset := size
+		size += int64(chunk.Length)
+
+		// Forget chunks that have left the window
+		for len(queue) > 0 && offset-queue[0].offset > window {
+			if offsets[queue[0].sum] == queue[0].offset {
+				delete(offsets, queue[0].sum)
+			}
+			queue = queue[1:]
+		}
+		sum := [sha256.Size]byte(chunk.Sum)
+		src, ok := offsets[sum]
+		offsets[sum] = offset
+		queue = append(queue, seen{sum, offset})
+
+		if repeatLength > 0 && (!ok || repeatOffset+repeatLength != src) {
+			if err := put(frameRepeat, uint64(repeatStart-repeatOffset), uint64(repeatLength)); err != nil {
+				return stats, err
+			}
+			repeatLength = 0
+		}
+		if ok {
+			if repeatLength == 0 {
+				repeatStart, repeatOffset = offset, src
+			}
+			repeatLength += int64(chunk.Length)
+			stats.Repeated += int64(chunk.Length)
+			continue
+		}
+
+		if err := put(frameLiteral, uint64(chunk.Length)); err != nil {
+			return stats, err
+		}
+		if _, err := bw.Write(chunk.Data); err != nil {
+			return stats, err
+		}
+		stats.Literal += int64(chunk.Length)
+	}
+
+	if repeatLength > 0 {
+		if err := put(frameRepeat, uint64(repeatStart-repeatOffset), uint64(repeatLength)); err != nil {
+			return stats, err
+		}
+	}
+	if err := put(frameEnd, uint64(size)); err != nil {
+		return stats, err
+	}
+	bw.Write(h.Sum(nil))
+	return stats, bw.Flush()
+}
+
+// Decode writes the stream encoded in r to w, and checks it against the
+// size and hash of the original. The stream is written before it is
+// checked, so it must be discarded on error.
+func Decode(w io.Writer, r io.Reader) error {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	var m [4]byte
+	if _, err := io.ReadFull(br, m[:]); err != nil || m != magic {
+		return fmt.Errorf("%w: bad magic", ErrFormat)
+	}
+	window, err := binary.ReadUvarint(br)
+	if err != nil || window > 1<<40 {
+		return fmt.Errorf("%w: bad window", ErrFormat)
+	}
+
+	h := sha256.New()
+	var size int64
+	var recent []byte // The last window bytes of output, and up to as many more
+	emit := func(data []byte) error {
+		h.Write(data)
+		size += int64(len(data))
+		_, err := w.Write(data)
+		if uint64(len(recent)) > 2*window {
+			recent = append(recent[:0], recent[uint64(len(recent))-window:]...)
+		}
+		return err
+	}
+	for {
+		frame, err := br.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+
+		switch frame {
+		case frameRepeat:
+			distance, err1 := binary.ReadUvarint(br)
+			length, err2 := binary.ReadUvarint(br)
+			if err := errors.Join(err1, err2); err != nil {
+				return fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			if distance == 0 || distance > window || distance > uint64(len(recent)) {
+				return fmt.Errorf("%w: reference %d bytes back out of the window", ErrFormat, distance)
+			}
+			// Copy in pieces no longer than distance, as the reference may
+			// overlap its own output
+			for length > 0 {
+				start := len(recent) - int(distance)
+				n := int(min(length, distance))
+				recent = append(recent, recent[start:start+n]...)
+				if err := emit(recent[len(recent)-n:]); err != nil {
+					return err
+				}
+				length -= uint64(n)
+			}
+
+		case frameLiteral:
+			length, err := binary.ReadUvarint(br)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			n := len(recent)
+			m, err := io.CopyN(sliceWriter{&recent}, br, int64(length))
+			if err := emit(recent[n : n+int(m)]); err != nil {
+				return err
+			}
+			if err == io.EOF {
+				return fmt.Errorf("%w: truncated literal", ErrFormat)
+			}
+			if err != nil {
+				return err
+			}
+
+		case frameEnd:
+			expectedSize, err := binary.ReadUvarint(br)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			var sum [sha256.Size]byte
+			if _, err := io.ReadFull(br, sum[:]); err != nil {
+				return fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			if size != int64(expectedSize) || [sha256.Size]byte(h.Sum(nil)) != sum {
+				return ErrMismatch
+			}
+			return nil
+
+		default:
+			return fmt.Errorf("%w: unknown frame %d", ErrFormat, frame)
+		}
+	}
+}
+
+// sliceWriter appends to a slice
+type sliceWriter struct{ p *[]byte }
+
+func (s sliceWriter) Write(p []byte) (int, error) {
+	*s.p = append(*s.p, p...)
+	return len(p), nil
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
@@ -0,0 +1,65 @@
+package framed
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	// Blocks repeating at different distances, and a run of zeros
+	block := datagen.LCG(200000, 1)
+	data := append(datagen.LCG(100000, 2), block...)
+	data = append(data, datagen.LCG(300000, 3)...)
+	data = append(data, block...)
+	data = append(data, make([]byte, 500000)...)
+	data = append(data, block...)
+
+	var enc bytes.Buffer
+	stats, err := Encode(&enc, bytes.NewReader(data), DefaultWindow)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Repeated+stats.Literal != int64(len(data)) {
+		t.Fatalf("expected stats to cover %d bytes, got %+v", len(data), stats)
+	}
+	if stats.Repeated < 700000 || enc.Len() > 800000 {
+		t.Fatalf("expected repeats to be referenced, got %d bytes with %+v", enc.Len(), stats)
+	}
+
+	var dec bytes.Buffer
+	if err := Decode(&dec, bytes.NewReader(enc.Bytes())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(dec.Bytes(), data) {
+		t.Fatalf("decoded stream does not match")
+	}
+
+	// Repeats further back than the window are sent again
+	var small bytes.Buffer
+	if _, err := Encode(&small, bytes.NewReader(data), 64*1024); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if small.Len() <= enc.Len() {
+		t.Fatalf("expected a larger stream with a small window, got %d bytes", small.Len())
+	}
+	dec.Reset()
+	if err := Decode(&dec, bytes.NewReader(small.Bytes())); err != nil || !bytes.Equal(dec.Bytes(), data) {
+		t.Fatalf("expected small window stream to decode, got %v", err)
+	}
+
+	// Corruption is detected
+	corrupt := bytes.Clone(enc.Bytes())
+	corrupt[1000]++
+	if err := Decode(&dec, bytes.NewReader(corrupt)); !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected ErrMismatch, got %v", err)
+	}
+	if err := Decode(&dec, bytes.NewReader(enc.Bytes()[:enc.Len()-40])); !errors.Is(err, ErrFormat) {
+		t.Fatalf("expected ErrFormat for truncated stream, got %v", err)
+	}
+	if err := Decode(&dec, bytes.NewReader([]byte("FCD1"))); !errors.Is(err, ErrFormat) {
+		t.Fatalf("expected ErrFormat for bad magic, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package fastcdc
+
+// WithGearTable makes the Chunker use table instead of the built-in gear
+// table G. Boundaries then differ from those of the default Chunker.
+func WithGearTable(table *[256]uint64) Option {
+	return func(c *Chunker) {
+		c.gear = table
+	}
+}
+
+// GearTable generates a gear table from seed using the splitmix64
+// generator, so that deployments can use a private table which makes
+// chunk boundaries harder to predict from content
+func GearTable(seed uint64) *[256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		seed += 0x9e3779b97f4a7c15
+		x := seed
+		x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+		x = (x ^ x>>27) * 0x94d049bb133111eb
+		table[i] = x ^ x>>31
+	}
+	return &table
+}
+
+// Masks returns the small and large masks used for the given average size.
+// The small mask, used before the average size, has more bits set so cut
+// points are less likely there.
+func Masks(avgSize int) (maskS, maskL uint64) {
+	var c Chunker
+	c.setParams(Params{AvgSize: avgSize})
+	return c.maskS, c.maskL
+}
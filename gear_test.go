@@ -0,0 +1,55 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestGearTable(t *testing.T) {
+	a, b := GearTable(1), GearTable(2)
+	if *a != *GearTable(1) {
+		t.Fatalf("GearTable is not deterministic")
+	}
+	if *a == *b {
+		t.Fatalf("different seeds give the same table")
+	}
+
+	data := make([]byte, 1*miB)
+	fillLCG(data, 42)
+
+	// A custom table changes boundaries but keeps data intact
+	chunker := NewChunker(bytes.NewReader(data), WithGearTable(a))
+	plain := map[int]bool{}
+	for _, chunk := range Rechunk(data, DefaultParams) {
+		plain[chunk.Offset] = true
+	}
+
+	same, total := 0, 0
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		if plain[chunk.Offset] {
+			same++
+		}
+		total += len(chunk.Data)
+	}
+	if total != len(data) {
+		t.Errorf("expected %d bytes in chunks, got %d", len(data), total)
+	}
+	if same > len(plain)/10 {
+		t.Errorf("expected mostly different boundaries, %d of %d are the same", same, len(plain))
+	}
+}
+
+func TestMasks(t *testing.T) {
+	maskS, maskL := Masks(8 * kiB)
+	if maskS != spread(15) || maskL != spread(11) {
+		t.Errorf("expected 15 and 11 bit masks, got %x and %x", maskS, maskL)
+	}
+}
@@ -0,0 +1,47 @@
+package fastcdc
+
+import (
+	"hash"
+	"sync"
+)
+
+// WithHash makes the Chunker hash each chunk with a hasher from newHash and
+// set Chunk.Sum. Hashers are pooled among the Chunkers sharing the option,
+// such as the workers of ChunkFiles, and reused from chunk to chunk, so
+// hashing adds no allocations once a stream is under way.
+func WithHash(newHash func() hash.Hash) Option {
+	pool := &sync.Pool{New: func() any {
+		h := newHash()
+		return &hasher{h: h, sum: make([]byte, 0, h.Size())}
+	}}
+	return func(c *Chunker) {
+		c.hashPool = pool
+	}
+}
+
+// hasher is a hash with a buffer for its sum
+type hasher struct {
+	h   hash.Hash
+	sum []byte
+}
+
+// hashSum returns the hash of data in a buffer that is reused on the next
+// call, taking a hasher from the pool on first use
+func (c *Chunker) hashSum(data []byte) []byte {
+	if c.hasher == nil {
+		c.hasher = c.hashPool.Get().(*hasher)
+	}
+	h := c.hasher
+	h.h.Reset()
+	h.h.Write(data)
+	h.sum = h.h.Sum(h.sum[:0])
+	return h.sum
+}
+
+// putHasher returns the hasher to the pool at the end of a stream
+func (c *Chunker) putHasher() {
+	if c.hasher != nil {
+		c.hashPool.Put(c.hasher)
+		c.hasher = nil
+	}
+}
@@ -0,0 +1,54 @@
+package fastcdc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	data := make([]byte, 256*kiB)
+	fillLCG(data, 3)
+
+	chunker := NewChunker(bytes.NewReader(data), WithHash(sha256.New))
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if sum := sha256.Sum256(chunk.Data); !bytes.Equal(chunk.Sum, sum[:]) {
+			t.Fatalf("chunk at %d: expected sum %x, got %x", chunk.Offset, sum, chunk.Sum)
+		}
+	}
+
+	chunk, err := NewChunker(bytes.NewReader(data)).Next()
+	if err != nil || chunk.Sum != nil {
+		t.Fatalf("expected no sum without WithHash, got %x (%v)", chunk.Sum, err)
+	}
+}
+
+func TestNextAllocs(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 4)
+
+	for _, opts := range [][]Option{nil, {WithHash(sha256.New)}} {
+		r := bytes.NewReader(data)
+		c := NewChunker(r, opts...)
+		allocs := testing.AllocsPerRun(10, func() {
+			r.Reset(data)
+			c.Reset(r)
+			for {
+				if _, err := c.Next(); err != nil {
+					break
+				}
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("expected no allocations with %d options, got %v", len(opts), allocs)
+		}
+	}
+}
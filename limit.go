@@ -0,0 +1,18 @@
+package fastcdc
+
+// WithMaxChunks makes Next return io.EOF after n chunks
+func WithMaxChunks(n int) Option {
+	return func(c *Chunker) {
+		c.maxChunks = n
+	}
+}
+
+// WithMaxBytes makes Next return io.EOF once chunks cover at least n bytes.
+// The chunk crossing the limit is returned whole, so every chunk has the
+// same boundaries as without the limit. At most n plus the maximum chunk
+// size bytes are read from the reader.
+func WithMaxBytes(n int) Option {
+	return func(c *Chunker) {
+		c.maxBytes = n
+	}
+}
@@ -0,0 +1,70 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
+
+func TestMaxChunks(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 42)
+	expected := Rechunk(data, DefaultParams)
+
+	chunker := NewChunker(bytes.NewReader(data), WithMaxChunks(5))
+	for i := 0; i < 5; i++ {
+		chunk, err := chunker.Next()
+		if err != nil {
+			t.Fatalf("error getting chunk %d: %v", i, err)
+		}
+		if chunk.Offset != expected[i].Offset || len(chunk.Data) != len(expected[i].Data) {
+			t.Errorf("unexpected chunk %d at %d", i, chunk.Offset)
+		}
+	}
+	if _, err := chunker.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after 5 chunks, got %v", err)
+	}
+}
+
+func TestMaxBytes(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 42)
+	expected := Rechunk(data, DefaultParams)
+
+	const limit = 100 * kiB
+	r := &countingReader{r: bytes.NewReader(data)}
+	chunker := NewChunker(r, WithMaxBytes(limit))
+
+	end := 0
+	for i := 0; ; i++ {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		if chunk.Offset != expected[i].Offset || len(chunk.Data) != len(expected[i].Data) {
+			t.Errorf("unexpected chunk %d at %d", i, chunk.Offset)
+		}
+		end = chunk.Offset + len(chunk.Data)
+	}
+
+	if end < limit || end-limit >= DefaultParams.MaxSize {
+		t.Errorf("expected chunks to end just past %d, got %d", limit, end)
+	}
+	if r.n > limit+DefaultParams.MaxSize {
+		t.Errorf("read %d bytes, expected at most %d", r.n, limit+DefaultParams.MaxSize)
+	}
+}
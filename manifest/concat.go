@@ -0,0 +1,98 @@
+package manifest
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrBoundary is returned by Splice for an offset inside a chunk
+var ErrBoundary = errors.New("manifest: offset not on a chunk boundary")
+
+// Concat returns the manifest of the objects of ms joined in order. All
+// manifests must have compatible headers, and the result has checksums
+// only if all of them do.
+func Concat(ms ...*Manifest) (*Manifest, error) {
+	n := 0
+	for _, m := range ms {
+		if err := m.Header.Compatible(ms[0].Header); err != nil {
+			return nil, err
+		}
+		n += len(m.Entries)
+	}
+
+	out := &Manifest{Entries: make([]Entry, 0, n)}
+	if len(ms) > 0 {
+		out.Header = ms[0].Header
+	}
+	offset := int64(0)
+	for _, m := range ms {
+		out.Entries = appendShifted(out.Entries, m.Entries, offset)
+		offset += m.Size()
+	}
+	for _, m := range ms {
+		if m.Header.Checksum != out.Header.Checksum {
+			out.dropChecksums()
+		}
+	}
+	return out, nil
+}
+
+// Splice returns a manifest where the length bytes at offset are replaced
+// by the object of repl. Both ends of the replaced range must be on chunk
+// boundaries of m, and repl must have a compatible header. The result has
+// checksums only if both do. To patch the manifest of an object after an
+// edit, use Rechunk. m is not modified.
+func (m *Manifest) Splice(offset, length int64, repl *Manifest) (*Manifest, error) {
+	if offset < 0 || length < 0 || offset+length > m.Size() {
+		return nil, ErrRange
+	}
+	if err := repl.Header.Compatible(m.Header); err != nil {
+		return nil, err
+	}
+	first, ok := m.boundary(offset)
+	if !ok {
+		return nil, ErrBoundary
+	}
+	last, ok := m.boundary(offset + length)
+	if !ok {
+		return nil, ErrBoundary
+	}
+
+	out := &Manifest{Header: m.Header, Entries: make([]Entry, 0, len(m.Entries)-(last-first)+len(repl.Entries))}
+	out.Entries = append(out.Entries, m.Entries[:first]...)
+	out.Entries = appendShifted(out.Entries, repl.Entries, offset)
+	out.Entries = appendShifted(out.Entries, m.Entries[last:], repl.Size()-length)
+	if repl.Header.Checksum != m.Header.Checksum {
+		out.dropChecksums()
+	}
+	return out, nil
+}
+
+// dropChecksums clears the checksums of m, as when some entries lack them
+func (m *Manifest) dropChecksums() {
+	m.Header.Checksum = ""
+	for i := range m.Entries {
+		m.Entries[i].CRC = 0
+	}
+}
+
+// boundary returns the index of the entry starting at offset, or the
+// number of entries for the end of the object
+func (m *Manifest) boundary(offset int64) (int, bool) {
+	i := sort.Search(len(m.Entries), func(i int) bool {
+		return m.Entries[i].Offset >= offset
+	})
+	if i == len(m.Entries) {
+		return i, offset == m.Size()
+	}
+	return i, m.Entries[i].Offset == offset
+}
+
+// appendShifted appends entries to dst with delta added to their offsets
+func appendShifted(dst, entries []Entry, delta int64) []Entry {
+	for _, e := range entries {
+		e.Offset += delta
+		dst = append(dst, e)
+	}
+	return dst
+}
@@ -0,0 +1,124 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func newManifest(t *testing.T, data []byte) *Manifest {
+	t.Helper()
+	m, err := New(bytes.NewReader(data), defaultHeader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return m
+}
+
+// checkManifest checks that entries are contiguous and hash the data
+func checkManifest(t *testing.T, m *Manifest, data []byte) {
+	t.Helper()
+	offset := int64(0)
+	for i, e := range m.Entries {
+		if e.Offset != offset {
+			t.Fatalf("entry %d: expected offset %d, got %d", i, offset, e.Offset)
+		}
+		if e.Offset+int64(e.Length) > int64(len(data)) {
+			t.Fatalf("entry %d: extends past data", i)
+		}
+		if e.Hash != sha256.Sum256(data[e.Offset:e.Offset+int64(e.Length)]) {
+			t.Fatalf("entry %d: hash mismatch", i)
+		}
+		offset += int64(e.Length)
+	}
+	if offset != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d", len(data), offset)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	a, b := datagen.LCG(100*1024, 1), datagen.LCG(50*1024+7, 2)
+	m, err := Concat(newManifest(t, a), &Manifest{Header: defaultHeader}, newManifest(t, b))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkManifest(t, m, append(bytes.Clone(a), b...))
+	if m.Header != defaultHeader {
+		t.Errorf("expected header %+v, got %+v", defaultHeader, m.Header)
+	}
+
+	if m, err := Concat(); err != nil || len(m.Entries) != 0 {
+		t.Errorf("expected empty manifest, got %v, %v", m, err)
+	}
+
+	other := DefaultHeader(fastcdc.Params{MinSize: 1024, AvgSize: 4096, MaxSize: 16384})
+	if _, err := Concat(newManifest(t, a), &Manifest{Header: other}); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("expected ErrIncompatible, got %v", err)
+	}
+
+	// Checksums may differ, and are kept only if all manifests have them
+	checked := defaultHeader
+	checked.Checksum = ChecksumCRC32C
+	ca, err := New(bytes.NewReader(a), checked)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	m, err = Concat(ca, newManifest(t, b))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkManifest(t, m, append(bytes.Clone(a), b...))
+	if m.Header != defaultHeader || m.Entries[0].CRC != 0 {
+		t.Errorf("expected header %+v without checksums, got %+v", defaultHeader, m.Header)
+	}
+	if m, err := Concat(ca, ca); err != nil || m.Header != checked || m.Entries[0].CRC != ca.Entries[0].CRC {
+		t.Errorf("expected header %+v with checksums, got %+v, %v", checked, m.Header, err)
+	}
+}
+
+func TestSplice(t *testing.T) {
+	data := datagen.LCG(256*1024, 1)
+	m := newManifest(t, data)
+
+	// Insert 1000 bytes into entry 10 and re-chunk just that entry
+	e := m.Entries[10]
+	edited := datagen.Shifted(data, int(e.Offset)+100, 1000, 2)
+	region := edited[e.Offset : e.Offset+int64(e.Length)+1000]
+
+	spliced, err := m.Splice(e.Offset, int64(e.Length), newManifest(t, region))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkManifest(t, spliced, edited)
+	if m.Size() != int64(len(data)) || m.Entries[11].Offset != e.Offset+int64(e.Length) {
+		t.Error("expected original manifest to be unchanged")
+	}
+
+	// Deleting whole entries and appending at the end
+	spliced, err = m.Splice(m.Entries[2].Offset, m.Entries[4].Offset-m.Entries[2].Offset, &Manifest{Header: defaultHeader})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkManifest(t, spliced, append(bytes.Clone(data[:m.Entries[2].Offset]), data[m.Entries[4].Offset:]...))
+
+	tail := datagen.LCG(1000, 3)
+	spliced, err = m.Splice(m.Size(), 0, newManifest(t, tail))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkManifest(t, spliced, append(bytes.Clone(data), tail...))
+
+	if _, err := m.Splice(e.Offset+1, 10, &Manifest{Header: defaultHeader}); err != ErrBoundary {
+		t.Errorf("expected ErrBoundary, got %v", err)
+	}
+	if _, err := m.Splice(m.Size(), 1, &Manifest{Header: defaultHeader}); err != ErrRange {
+		t.Errorf("expected ErrRange, got %v", err)
+	}
+	if _, err := m.Splice(0, 0, &Manifest{}); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("expected ErrIncompatible, got %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+const (
+	VariantFastCDC = fastcdc.VariantFastCDC // Gear hash with two masks, as fastcdc.Chunker
+	VariantTwoByte = fastcdc.VariantTwoByte // With fastcdc.WithTwoByteScan
+	HashSHA256     = "sha256"               // SHA-256 of the chunk data
+	ChecksumCRC32C = "crc32c"               // CRC32C of the chunk data
+
+	// DefaultNormalization is the normalization level of fastcdc.Chunker,
+	// the number of bits added to and removed from the average mask
+	DefaultNormalization = fastcdc.Normalization
+
+	// MinHashSize is the smallest HashSize. Hashes of 16 bytes take an
+	// entry of an 8 KiB chunk from 34 to 18 bytes, and keep collisions
+	// unlikely for any practical number of chunks.
+	MinHashSize = 8
+)
+
+// ErrChecksum is returned when chunk data does not match its entry
+var ErrChecksum = errors.New("manifest: chunk does not match its entry")
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrIncompatible is returned when manifests produced with different
+// chunking settings are combined, as their chunks would not dedup
+var ErrIncompatible = errors.New("manifest: incompatible chunking settings")
+
+// Header records how the chunks of a manifest were produced
+type Header struct {
+	Params        fastcdc.Params
+	Variant       string // Chunking algorithm
+	Normalization int    // Normalization level of the masks
+	GearID        uint64 // Identifies the gear table, 0 for the default
+	HashAlgorithm string // Hash of the chunk data
+	Checksum      string // Checksum of each entry for quick checks, or none
+	Encoding      string // Compression of the source, whose chunks are decompressed
+	HashSize      int    // Leading bytes of each hash kept, 0 for all, the rest are zero
+}
+
+// DefaultHeader returns the header for chunks produced by fastcdc.Chunker
+// with params p and the default gear table
+func DefaultHeader(p fastcdc.Params) Header {
+	return Header{
+		Params:        p,
+		Variant:       VariantFastCDC,
+		Normalization: DefaultNormalization,
+		HashAlgorithm: HashSHA256,
+	}
+}
+
+// GearID returns the ID of a gear table for use in a Header, derived from
+// a hash of the table. The default table fastcdc.G has ID 0.
+func GearID(table *[256]uint64) uint64 {
+	if table == nil || *table == fastcdc.G {
+		return 0
+	}
+	buf := make([]byte, 0, len(table)*8)
+	for _, v := range table {
+		buf = binary.LittleEndian.AppendUint64(buf, v)
+	}
+	sum := sha256.Sum256(buf)
+	return binary.LittleEndian.Uint64(sum[:]) | 1
+}
+
+// Compatible returns an error wrapping ErrIncompatible if chunks produced
+// with h and o may have different boundaries or hashes for the same data.
+// Checksums do not affect either, so they may differ.
+func (h Header) Compatible(o Header) error {
+	h.Checksum, o.Checksum = "", ""
+	if h != o {
+		return fmt.Errorf("%w: %+v and %+v", ErrIncompatible, h, o)
+	}
+	return nil
+}
+
+// checkConfig returns an error wrapping ErrIncompatible if a Chunker with
+// cfg may cut differently from the one that produced h. Only the presence
+// of a custom gear table is checked, as cfg does not identify it. Headers
+// without a variant, as of version 1, record no settings to check.
+func (h Header) checkConfig(cfg fastcdc.Config) error {
+	if h.Variant == "" {
+		return nil
+	}
+	if cfg.Params != h.Params || cfg.Variant != h.Variant || cfg.Normalization != h.Normalization || cfg.CustomGear != (h.GearID != 0) {
+		return fmt.Errorf("%w: header %+v, chunker %+v", ErrIncompatible, h, cfg)
+	}
+	return nil
+}
+
+// QuickCheck returns ErrChecksum if data does not match entry e. With
+// checksums it compares the CRC32C, which detects corruption at a fraction
+// of the cost of the cryptographic hash used otherwise, but does not
+// establish identity.
+func (h Header) QuickCheck(e Entry, data []byte) error {
+	ok := len(data) == e.Length
+	if ok && h.Checksum == ChecksumCRC32C {
+		ok = crc32.Checksum(data, castagnoli) == e.CRC
+	} else if ok {
+		ok = h.sum(data) == e.Hash
+	}
+	if !ok {
+		return fmt.Errorf("%w: chunk at %d", ErrChecksum, e.Offset)
+	}
+	return nil
+}
+
+// sum returns the hash of data, truncated to the hash size
+func (h Header) sum(data []byte) Hash {
+	sum := sha256.Sum256(data)
+	clear(sum[h.hashSize():])
+	return sum
+}
+
+// hashSize returns the number of bytes of each hash kept
+func (h Header) hashSize() int {
+	if h.HashSize <= 0 || h.HashSize > sha256.Size {
+		return sha256.Size
+	}
+	return h.HashSize
+}
+
+// checkEntries returns an error wrapping ErrFormat if the entries of h
+// cannot be encoded, for an unsupported checksum or hash size
+func (h Header) checkEntries() error {
+	if h.Checksum != "" && h.Checksum != ChecksumCRC32C {
+		return fmt.Errorf("%w: unsupported checksum %q", ErrFormat, h.Checksum)
+	}
+	return h.checkHashSize()
+}
+
+// checkHashSize returns an error wrapping ErrFormat if the hash size of h
+// is out of range
+func (h Header) checkHashSize() error {
+	if h.HashSize != 0 && (h.HashSize < MinHashSize || h.HashSize > sha256.Size) {
+		return fmt.Errorf("%w: hash size %d not between %d and %d", ErrFormat, h.HashSize, MinHashSize, sha256.Size)
+	}
+	return nil
+}
+
+// Header fields are encoded as a varint tag, a varint length and the
+// payload, and end with tag 0. Odd tags mark fields that affect chunk
+// boundaries, hashes or the entry encoding.
+const (
+	tagEnd           = 0
+	tagParams        = 1
+	tagVariant       = 3
+	tagNormalization = 5
+	tagGearID        = 7
+	tagHash          = 9
+	tagChecksum      = 11
+	tagEncoding      = 12
+	tagHashSize      = 13
+)
+
+func appendHeader(buf []byte, h Header) []byte {
+	field := func(tag uint64, payload []byte) {
+		buf = binary.AppendUvarint(buf, tag)
+		buf = binary.AppendUvarint(buf, uint64(len(payload)))
+		buf = append(buf, payload...)
+	}
+
+	var params []byte
+	params = binary.AppendUvarint(params, uint64(h.Params.MinSize))
+	params = binary.AppendUvarint(params, uint64(h.Params.AvgSize))
+	params = binary.AppendUvarint(params, uint64(h.Params.MaxSize))
+	field(tagParams, params)
+	field(tagVariant, []byte(h.Variant))
+	field(tagNormalization, binary.AppendUvarint(nil, uint64(h.Normalization)))
+	field(tagGearID, binary.AppendUvarint(nil, h.GearID))
+	field(tagHash, []byte(h.HashAlgorithm))
+	if h.Checksum != "" {
+		field(tagChecksum, []byte(h.Checksum))
+	}
+	if h.Encoding != "" {
+		field(tagEncoding, []byte(h.Encoding))
+	}
+	if h.HashSize != 0 {
+		field(tagHashSize, binary.AppendUvarint(nil, uint64(h.HashSize)))
+	}
+	return binary.AppendUvarint(buf, tagEnd)
+}
+
+// readHeader decodes header fields, skipping unknown ones unless strict
+// is set and they are critical
+func readHeader(r *countReader, strict bool) (Header, error) {
+	var h Header
+	for {
+		tag, err := binary.ReadUvarint(r)
+		if err != nil {
+			return h, formatError(err)
+		}
+		if tag == tagEnd {
+			return h, nil
+		}
+		length, err := binary.ReadUvarint(r)
+		if err != nil {
+			return h, formatError(err)
+		}
+		if length > 1<<16 {
+			return h, fmt.Errorf("%w: header field %d too long", ErrFormat, tag)
+		}
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return h, formatError(err)
+		}
+
+		var values []uint64
+		for p := payload; len(p) > 0; {
+			v, n := binary.Uvarint(p)
+			if n <= 0 {
+				break
+			}
+			values = append(values, v)
+			p = p[n:]
+		}
+
+		switch tag {
+		case tagParams:
+			if len(values) != 3 {
+				return h, fmt.Errorf("%w: bad params field", ErrFormat)
+			}
+			h.Params = fastcdc.Params{MinSize: int(values[0]), AvgSize: int(values[1]), MaxSize: int(values[2])}
+		case tagVariant:
+			h.Variant = string(payload)
+		case tagNormalization:
+			if len(values) != 1 {
+				return h, fmt.Errorf("%w: bad normalization field", ErrFormat)
+			}
+			h.Normalization = int(values[0])
+		case tagGearID:
+			if len(values) != 1 {
+				return h, fmt.Errorf("%w: bad gear ID field", ErrFormat)
+			}
+			h.GearID = values[0]
+		case tagHash:
+			h.HashAlgorithm = string(payload)
+		case tagChecksum:
+			h.Checksum = string(payload)
+		case tagEncoding:
+			h.Encoding = string(payload)
+		case tagHashSize:
+			if len(values) != 1 || values[0] > sha256.Size {
+				return h, fmt.Errorf("%w: bad hash size field", ErrFormat)
+			}
+			h.HashSize = int(values[0])
+		default:
+			if strict && tag%2 == 1 {
+				return h, fmt.Errorf("%w: unknown critical header field %d", ErrFormat, tag)
+			}
+		}
+	}
+}
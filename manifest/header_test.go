@@ -0,0 +1,236 @@
+package manifest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestGearID(t *testing.T) {
+	if id := GearID(nil); id != 0 {
+		t.Errorf("expected 0 for nil table, got %d", id)
+	}
+	if id := GearID(&fastcdc.G); id != 0 {
+		t.Errorf("expected 0 for default table, got %d", id)
+	}
+	a, b := GearID(fastcdc.GearTable(1)), GearID(fastcdc.GearTable(2))
+	if a == 0 || b == 0 || a == b {
+		t.Errorf("expected distinct non-zero IDs, got %d and %d", a, b)
+	}
+	if a != GearID(fastcdc.GearTable(1)) {
+		t.Error("expected ID to be stable")
+	}
+}
+
+func TestHeader(t *testing.T) {
+	table := fastcdc.GearTable(42)
+	h := DefaultHeader(fastcdc.Params{MinSize: 1024, AvgSize: 4096, MaxSize: 16384})
+	h.GearID = GearID(table)
+
+	data := datagen.LCG(100*1024, 1)
+	m, err := New(bytes.NewReader(data), h, fastcdc.WithGearTable(table))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := fastcdc.Rechunk(data, h.Params)
+	if len(m.Entries) == len(expected) && m.Entries[0].Length == expected[0].Length {
+		t.Error("expected custom gear table to change boundaries")
+	}
+	for _, e := range m.Entries[:len(m.Entries)-1] {
+		if e.Length < h.Params.MinSize || e.Length > h.Params.MaxSize {
+			t.Fatalf("expected header params to be used, got length %d", e.Length)
+		}
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var decoded Manifest
+	if _, err := decoded.ReadFrom(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Header != h {
+		t.Errorf("expected header %+v, got %+v", h, decoded.Header)
+	}
+
+	if err := h.Compatible(h); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := h.Compatible(DefaultHeader(h.Params)); !errors.Is(err, ErrIncompatible) {
+		t.Errorf("expected ErrIncompatible, got %v", err)
+	}
+}
+
+func TestHeaderUnknownField(t *testing.T) {
+	// A field from a future version is skipped
+	enc := binary.AppendUvarint(magic[:], Version)
+	enc = append(enc, 100, 3, 'a', 'b', 'c')
+	enc = appendHeader(enc, defaultHeader)
+	enc = append(enc, 0, 0)
+
+	r := NewReader(bytes.NewReader(enc))
+	h, err := r.Header()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if h != defaultHeader {
+		t.Errorf("expected header %+v, got %+v", defaultHeader, h)
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	data := datagen.LCG(200*1024, 2)
+	h := DefaultHeader(fastcdc.DefaultParams)
+	h.Checksum = ChecksumCRC32C
+	m, err := New(bytes.NewReader(data), h)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	plain, _ := New(bytes.NewReader(data), DefaultHeader(fastcdc.DefaultParams))
+
+	var buf, plainBuf bytes.Buffer
+	m.WriteTo(&buf)
+	plain.WriteTo(&plainBuf)
+	if expected := plainBuf.Len() + len(m.Entries)*4 + 8; buf.Len() != expected {
+		t.Errorf("expected %d bytes with checksums, got %d", expected, buf.Len())
+	}
+
+	mr := NewReader(bytes.NewReader(buf.Bytes()))
+	if v, err := mr.Version(); v != Version3 || err != nil {
+		t.Fatalf("expected version %d, got %d (%v)", Version3, v, err)
+	}
+	var decoded Manifest
+	if _, err := decoded.ReadFrom(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var fromProto Manifest
+	if err := fromProto.UnmarshalProto(m.MarshalProto()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Header != h || fromProto.Header != h {
+		t.Fatalf("expected header %+v, got %+v and %+v", h, decoded.Header, fromProto.Header)
+	}
+	for i, e := range m.Entries {
+		if decoded.Entries[i] != e || fromProto.Entries[i] != e {
+			t.Fatalf("entry %d: expected %+v, got %+v and %+v", i, e, decoded.Entries[i], fromProto.Entries[i])
+		}
+	}
+
+	// Quick checks use the checksum if there is one, the hash otherwise
+	for _, m := range []*Manifest{m, plain} {
+		e := m.Entries[1]
+		chunk := bytes.Clone(data[e.Offset : e.Offset+int64(e.Length)])
+		if err := m.Header.QuickCheck(e, chunk); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		chunk[10]++
+		if err := m.Header.QuickCheck(e, chunk); !errors.Is(err, ErrChecksum) {
+			t.Errorf("expected ErrChecksum, got %v", err)
+		}
+	}
+}
+
+func TestHashSize(t *testing.T) {
+	data := datagen.LCG(400*1024, 3)
+	plain := newManifest(t, data)
+	for _, checksum := range []string{"", ChecksumCRC32C} {
+		h := defaultHeader
+		h.HashSize, h.Checksum = 16, checksum
+		m, err := New(bytes.NewReader(data), h)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var buf, plainBuf bytes.Buffer
+		m.WriteTo(&buf)
+		plain.WriteTo(&plainBuf)
+		// Entries lose 16 bytes of hash, and the header gains a 3-byte field
+		perEntry, header := 16-32, 3
+		if checksum != "" {
+			perEntry, header = perEntry+4, header+2+len(checksum)
+		}
+		if expected := plainBuf.Len() + len(m.Entries)*perEntry + header; buf.Len() != expected {
+			t.Errorf("checksum %q: expected %d bytes, got %d", checksum, expected, buf.Len())
+		}
+		if v, err := NewReader(bytes.NewReader(buf.Bytes())).Version(); v != Version4 || err != nil {
+			t.Fatalf("expected version %d, got %d (%v)", Version4, v, err)
+		}
+
+		var decoded, fromProto Manifest
+		if _, err := decoded.ReadFrom(&buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := fromProto.UnmarshalProto(m.MarshalProto()); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if decoded.Header != h || fromProto.Header != h {
+			t.Fatalf("expected header %+v, got %+v and %+v", h, decoded.Header, fromProto.Header)
+		}
+		for i, e := range m.Entries {
+			truncated := plain.Entries[i].Hash
+			clear(truncated[16:])
+			if e.Hash != truncated || decoded.Entries[i] != e || fromProto.Entries[i] != e {
+				t.Fatalf("entry %d: expected %+v, got %+v and %+v", i, e, decoded.Entries[i], fromProto.Entries[i])
+			}
+		}
+		if err := Verify(&decoded, bytes.NewReader(data)); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		e := m.Entries[1]
+		if err := h.QuickCheck(e, data[e.Offset:e.Offset+int64(e.Length)]); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+
+	// Sizes out of range are not written, and truncated hashes need version 4
+	h := defaultHeader
+	h.HashSize = MinHashSize - 1
+	if err := NewWriter(io.Discard, h).Add(plain.Entries[0]); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+	h.HashSize = 16
+	enc := appendHeader(binary.AppendUvarint(magic[:], Version2), h)
+	if _, err := NewReader(bytes.NewReader(append(enc, 0, 0))).Header(); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+}
+
+func TestHeaderEncoding(t *testing.T) {
+	data := datagen.LCG(100*1024, 3)
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write(data)
+	zw.Close()
+
+	h := DefaultHeader(fastcdc.DefaultParams)
+	m, err := New(&gz, h, fastcdc.WithTransparentDecompression())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if m.Header.Encoding != fastcdc.EncodingGzip || m.Size() != int64(len(data)) {
+		t.Fatalf("expected %d bytes of gzip source, got %d of %q", len(data), m.Size(), m.Header.Encoding)
+	}
+
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+	var decoded, fromProto Manifest
+	if _, err := decoded.ReadFrom(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := fromProto.UnmarshalProto(m.MarshalProto()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Header != m.Header || fromProto.Header != m.Header {
+		t.Errorf("expected header %+v, got %+v and %+v", m.Header, decoded.Header, fromProto.Header)
+	}
+}
@@ -0,0 +1,157 @@
+// Package manifest describes objects as lists of chunk hashes and stores
+// them in a compact binary encoding.
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// Versions of the binary encoding. Version 1 has no header fields,
+// version 3 adds a checksum to each entry, and version 4 truncates hashes
+// to the hash size of the header, with or without checksums. Readers
+// decode all versions, and Upgrade converts older ones.
+const (
+	Version1 = 1
+	Version2 = 2
+	Version3 = 3
+	Version4 = 4
+
+	Version = Version2 // Written by WriteTo and Writer, or Version3 with checksums and Version4 with a hash size
+)
+
+var magic = [4]byte{'F', 'C', 'D', 'M'}
+
+// ErrFormat is wrapped by errors for malformed or unsupported encodings
+var ErrFormat = errors.New("manifest: invalid format")
+
+// Hash is the SHA-256 hash of a chunk
+type Hash [sha256.Size]byte
+
+func (h Hash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// Entry is one chunk of an object
+type Entry struct {
+	Offset int64
+	Length int
+	Hash   Hash
+	CRC    uint32 // CRC32C of the chunk if the header has Checksum set
+}
+
+// Manifest lists the chunks of an object in order
+type Manifest struct {
+	Header  Header
+	Entries []Entry
+}
+
+// New chunks r with the params of h and the given options, and returns
+// its manifest. A custom gear table in opts must be identified by
+// h.GearID. With fastcdc.WithTransparentDecompression, the detected
+// encoding is recorded in the header.
+func New(r io.Reader, h Header, opts ...fastcdc.Option) (*Manifest, error) {
+	m := &Manifest{Header: h}
+	c := fastcdc.NewChunker(r, append([]fastcdc.Option{fastcdc.WithParams(h.Params)}, opts...)...)
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			if enc := c.Encoding(); enc != fastcdc.EncodingNone {
+				m.Header.Encoding = enc
+			}
+			return m, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		m.Entries = append(m.Entries, h.entry(chunk))
+	}
+}
+
+// NewFile is like New for the file at path, chunked with
+// fastcdc.ChunkFile. With fastcdc.WithFilePolicy, the header records the
+// params chosen for the file, and a skipped file has a nil manifest.
+func NewFile(path string, h Header, opts ...fastcdc.Option) (*Manifest, error) {
+	var m *Manifest
+	var entries []Entry
+	opts = append([]fastcdc.Option{fastcdc.WithParams(h.Params)}, opts...)
+	opts = append(opts, fastcdc.WithFileResult(func(_ string, r fastcdc.FileResult) {
+		m = &Manifest{Header: h}
+		m.Header.Params = r.Params
+		if r.Encoding != fastcdc.EncodingNone {
+			m.Header.Encoding = r.Encoding
+		}
+	}))
+	err := fastcdc.ChunkFile(path, func(c fastcdc.Chunk) error {
+		entries = append(entries, h.entry(c))
+		return nil
+	}, opts...)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	m.Entries = entries
+	return m, nil
+}
+
+// entry returns the entry for a chunk, hashing its data
+func (h Header) entry(c fastcdc.Chunk) Entry {
+	e := Entry{Offset: int64(c.Offset), Length: c.Length, Hash: h.sum(c.Data)}
+	if h.Checksum == ChecksumCRC32C {
+		e.CRC = crc32.Checksum(c.Data, castagnoli)
+	}
+	return e
+}
+
+// Size returns the total size of the object
+func (m *Manifest) Size() int64 {
+	if len(m.Entries) == 0 {
+		return 0
+	}
+	last := m.Entries[len(m.Entries)-1]
+	return last.Offset + int64(last.Length)
+}
+
+// WriteTo writes the binary encoding of m to w. It starts with a magic,
+// version and the header fields, followed by a varint length and the hash
+// of each entry, truncated to the hash size, and its little-endian CRC32C
+// with checksums. Offsets are implied by the lengths. A zero length ends
+// the entries and is followed by the varint entry count to detect
+// truncation. With 8 KiB chunks an entry takes 34 bytes, or 18 with a
+// hash size of 16.
+func (m *Manifest) WriteTo(w io.Writer) (int64, error) {
+	mw := NewWriter(w, m.Header)
+	for _, e := range m.Entries {
+		if err := mw.Add(e); err != nil {
+			return mw.cw.n, err
+		}
+	}
+	err := mw.Close()
+	return mw.cw.n, err
+}
+
+// ReadFrom replaces the header and entries of m with ones decoded from r.
+// The header of a version 1 encoding is left empty.
+func (m *Manifest) ReadFrom(r io.Reader) (int64, error) {
+	mr := NewReader(r)
+	m.Entries = m.Entries[:0]
+
+	var err error
+	if m.Header, err = mr.Header(); err != nil {
+		return mr.cr.n, err
+	}
+	for {
+		e, err := mr.Next()
+		if err == io.EOF {
+			return mr.cr.n, nil
+		}
+		if err != nil {
+			return mr.cr.n, err
+		}
+		m.Entries = append(m.Entries, e)
+	}
+}
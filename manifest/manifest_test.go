@@ -0,0 +1,137 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+var defaultHeader = DefaultHeader(fastcdc.DefaultParams)
+
+func TestNew(t *testing.T) {
+	data := datagen.LCG(1024*1024, 1)
+	m, err := New(bytes.NewReader(data), defaultHeader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := fastcdc.Rechunk(data, fastcdc.DefaultParams)
+	if len(m.Entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(m.Entries))
+	}
+	for i, e := range m.Entries {
+		c := expected[i]
+		if e.Offset != int64(c.Offset) || e.Length != c.Length || e.Hash != sha256.Sum256(c.Data) {
+			t.Fatalf("entry %d: expected chunk at %d of %d bytes, got %+v", i, c.Offset, c.Length, e)
+		}
+	}
+	if m.Size() != int64(len(data)) {
+		t.Errorf("expected size %d, got %d", len(data), m.Size())
+	}
+}
+
+func TestNewFile(t *testing.T) {
+	dir := t.TempDir()
+	data := datagen.LCG(256*1024, 3)
+	small := fastcdc.Params{MinSize: 512, AvgSize: 2048, MaxSize: 8192}
+	policy := func(path string, size int64) (fastcdc.Params, bool) {
+		switch filepath.Ext(path) {
+		case ".txt":
+			return small, true
+		case ".tmp":
+			return fastcdc.Params{}, false
+		}
+		return fastcdc.DefaultParams, true
+	}
+
+	for name, expected := range map[string]*fastcdc.Params{"a.txt": &small, "b.bin": &fastcdc.DefaultParams, "c.tmp": nil} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		m, err := NewFile(path, defaultHeader, fastcdc.WithFilePolicy(policy))
+		switch {
+		case err != nil:
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		case expected == nil:
+			if m != nil {
+				t.Errorf("%s: expected no manifest for skipped file, got %d entries", name, len(m.Entries))
+			}
+			continue
+		case m.Header.Params != *expected:
+			t.Errorf("%s: expected params %v in header, got %v", name, *expected, m.Header.Params)
+		}
+		checkManifest(t, m, data)
+		if len(m.Entries) != len(fastcdc.Rechunk(data, *expected)) {
+			t.Errorf("%s: expected %d entries, got %d", name, len(fastcdc.Rechunk(data, *expected)), len(m.Entries))
+		}
+	}
+}
+
+func TestEncoding(t *testing.T) {
+	for _, size := range []int{0, 100, 4 * 1024 * 1024} {
+		m, err := New(bytes.NewReader(datagen.LCG(size, 2)), defaultHeader)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var buf bytes.Buffer
+		n, err := m.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("expected %d bytes written, got %d", buf.Len(), n)
+		}
+		if perEntry := (buf.Len() - 50) / max(len(m.Entries), 1); perEntry > 35 {
+			t.Errorf("expected at most 35 bytes per entry, got %d", perEntry)
+		}
+
+		var decoded Manifest
+		n, err = decoded.ReadFrom(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("size %d: unexpected error: %v", size, err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("expected %d bytes read, got %d", buf.Len(), n)
+		}
+		if decoded.Header != m.Header {
+			t.Errorf("expected header %+v, got %+v", m.Header, decoded.Header)
+		}
+		if len(decoded.Entries) != len(m.Entries) {
+			t.Fatalf("expected %d entries, got %d", len(m.Entries), len(decoded.Entries))
+		}
+		for i := range m.Entries {
+			if decoded.Entries[i] != m.Entries[i] {
+				t.Fatalf("entry %d: expected %+v, got %+v", i, m.Entries[i], decoded.Entries[i])
+			}
+		}
+
+		// Any truncation is detected
+		for _, cut := range []int{0, 3, buf.Len() / 2, buf.Len() - 1} {
+			if _, err := decoded.ReadFrom(bytes.NewReader(buf.Bytes()[:cut])); !errors.Is(err, ErrFormat) {
+				t.Errorf("truncated at %d: expected ErrFormat, got %v", cut, err)
+			}
+		}
+	}
+}
+
+func TestEncodingErrors(t *testing.T) {
+	var m Manifest
+	for _, data := range []string{"XCDM\x01\x00\x00", "FCDM\x02\x00\x00", "FCDM\x01\x00\x01"} {
+		if _, err := m.ReadFrom(bytes.NewReader([]byte(data))); !errors.Is(err, ErrFormat) {
+			t.Errorf("%q: expected ErrFormat, got %v", data, err)
+		}
+	}
+
+	gap := Manifest{Entries: []Entry{{Offset: 0, Length: 10}, {Offset: 20, Length: 10}}}
+	if _, err := gap.WriteTo(&bytes.Buffer{}); err == nil {
+		t.Error("expected error for non-contiguous entries")
+	}
+}
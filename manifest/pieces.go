@@ -0,0 +1,55 @@
+package manifest
+
+// PieceMap maps the chunks of an object onto fixed-size pieces, as
+// distributed by BitTorrent and similar protocols, and back. Peers that
+// hold chunks from an earlier version of the object can then tell which
+// pieces they can assemble without downloading.
+type PieceMap struct {
+	m         *Manifest
+	pieceSize int64
+}
+
+// Pieces returns the map of m onto pieces of pieceSize bytes, the last of
+// which may be shorter. PieceSize must be positive.
+func (m *Manifest) Pieces(pieceSize int64) *PieceMap {
+	if pieceSize <= 0 {
+		panic("manifest: piece size not positive")
+	}
+	return &PieceMap{m: m, pieceSize: pieceSize}
+}
+
+// Len returns the number of pieces
+func (p *PieceMap) Len() int {
+	return int((p.m.Size() + p.pieceSize - 1) / p.pieceSize)
+}
+
+// Piece returns the run of entries making up piece i
+func (p *PieceMap) Piece(i int) (Range, error) {
+	offset := int64(i) * p.pieceSize
+	return p.m.Slice(offset, min(p.pieceSize, p.m.Size()-offset))
+}
+
+// ChunkPieces returns the first and last piece overlapping entry i
+func (p *PieceMap) ChunkPieces(i int) (first, last int) {
+	e := p.m.Entries[i]
+	return int(e.Offset / p.pieceSize), int((e.Offset + int64(e.Length) - 1) / p.pieceSize)
+}
+
+// Missing returns the pieces that have a chunk for which have returns
+// false, in order
+func (p *PieceMap) Missing(have func(Hash) bool) []int {
+	var missing []int
+	for i, e := range p.m.Entries {
+		if have(e.Hash) {
+			continue
+		}
+		first, last := p.ChunkPieces(i)
+		if n := len(missing); n > 0 && missing[n-1] >= first {
+			first = missing[n-1] + 1
+		}
+		for piece := first; piece <= last; piece++ {
+			missing = append(missing, piece)
+		}
+	}
+	return missing
+}
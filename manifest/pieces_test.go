@@ -0,0 +1,68 @@
+package manifest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestPieces(t *testing.T) {
+	old := datagen.LCG(1024*1024, 6)
+	data := bytes.Clone(old)
+	copy(data[500*1024:], datagen.LCG(1000, 7))
+
+	const pieceSize = 64 * 1024
+	m := newManifest(t, data)
+	p := m.Pieces(pieceSize)
+	if p.Len() != 16 {
+		t.Fatalf("expected 16 pieces, got %d", p.Len())
+	}
+
+	// Each piece assembles from its entries, which map back to it
+	for i := range p.Len() {
+		r, err := p.Piece(i)
+		if err != nil {
+			t.Fatalf("piece %d: unexpected error: %v", i, err)
+		}
+		var piece []byte
+		for _, e := range r.Entries {
+			piece = append(piece, data[e.Offset:e.Offset+int64(e.Length)]...)
+		}
+		piece = piece[r.Skip : len(piece)-r.Trim]
+		if !bytes.Equal(piece, data[i*pieceSize:(i+1)*pieceSize]) {
+			t.Fatalf("piece %d does not match", i)
+		}
+	}
+	for i, e := range m.Entries {
+		first, last := p.ChunkPieces(i)
+		if int64(first) != e.Offset/pieceSize || int64(last) != (e.Offset+int64(e.Length)-1)/pieceSize {
+			t.Fatalf("entry %d: unexpected pieces %d-%d", i, first, last)
+		}
+	}
+
+	// With the chunks of the old version, only pieces around the change
+	// are missing
+	have := make(map[Hash]bool)
+	for _, e := range newManifest(t, old).Entries {
+		have[e.Hash] = true
+	}
+	missing := p.Missing(func(h Hash) bool { return have[h] })
+	if len(missing) == 0 || len(missing) > 2 || missing[0] > 500*1024/pieceSize || missing[len(missing)-1] < (500*1024+999)/pieceSize {
+		t.Fatalf("expected pieces around offset %d missing, got %v", 500*1024, missing)
+	}
+}
+
+func TestPiecesSize(t *testing.T) {
+	m := newManifest(t, datagen.LCG(1000, 8))
+	for _, size := range []int64{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("size %d: expected a panic", size)
+				}
+			}()
+			m.Pieces(size)
+		}()
+	}
+}
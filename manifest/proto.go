@@ -0,0 +1,210 @@
+package manifest
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Protocol Buffers wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// MarshalProto returns the Protocol Buffers encoding of m, as described by
+// the Manifest message in manifest.proto
+func (m *Manifest) MarshalProto() []byte {
+	var params, header []byte
+	params = appendVarintField(params, 1, uint64(m.Header.Params.MinSize))
+	params = appendVarintField(params, 2, uint64(m.Header.Params.AvgSize))
+	params = appendVarintField(params, 3, uint64(m.Header.Params.MaxSize))
+
+	header = appendBytesField(header, 1, params)
+	header = appendBytesField(header, 2, []byte(m.Header.Variant))
+	header = appendVarintField(header, 3, uint64(m.Header.Normalization))
+	if m.Header.GearID != 0 {
+		header = binary.AppendUvarint(header, 4<<3|wireFixed64)
+		header = binary.LittleEndian.AppendUint64(header, m.Header.GearID)
+	}
+	header = appendBytesField(header, 5, []byte(m.Header.HashAlgorithm))
+	header = appendBytesField(header, 6, []byte(m.Header.Checksum))
+	header = appendBytesField(header, 7, []byte(m.Header.Encoding))
+	header = appendVarintField(header, 8, uint64(m.Header.HashSize))
+
+	buf := appendBytesField(nil, 1, header)
+	var chunk []byte
+	for _, e := range m.Entries {
+		chunk = appendVarintField(chunk[:0], 1, uint64(e.Offset))
+		chunk = appendVarintField(chunk, 2, uint64(e.Length))
+		chunk = appendBytesField(chunk, 3, e.Hash[:m.Header.hashSize()])
+		if e.CRC != 0 {
+			chunk = binary.AppendUvarint(chunk, 4<<3|wireFixed32)
+			chunk = binary.LittleEndian.AppendUint32(chunk, e.CRC)
+		}
+		buf = binary.AppendUvarint(buf, 2<<3|wireBytes)
+		buf = binary.AppendUvarint(buf, uint64(len(chunk)))
+		buf = append(buf, chunk...)
+	}
+	return buf
+}
+
+// UnmarshalProto replaces the header and entries of m with ones decoded
+// from the Protocol Buffers encoding in data. Unknown fields are skipped.
+func (m *Manifest) UnmarshalProto(data []byte) error {
+	*m = Manifest{}
+
+	// Fields may come in any order, so chunks are decoded once the header
+	// that tells their encoding is complete
+	var chunks [][]byte
+	err := parseFields(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			return m.Header.unmarshalProto(b)
+		case 2:
+			chunks = append(chunks, b)
+		}
+		return nil
+	})
+	if err == nil {
+		err = m.Header.checkEntries()
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, b := range chunks {
+		var e Entry
+		if err := e.unmarshalProto(b, m.Header.hashSize()); err != nil {
+			return err
+		}
+		if e.Offset != m.Size() || e.Length <= 0 {
+			return fmt.Errorf("%w: chunk %d is not contiguous", ErrFormat, len(m.Entries))
+		}
+		if e.CRC != 0 && m.Header.Checksum == "" {
+			return fmt.Errorf("%w: chunk %d has a checksum the header does not", ErrFormat, len(m.Entries))
+		}
+		m.Entries = append(m.Entries, e)
+	}
+	return nil
+}
+
+func (h *Header) unmarshalProto(data []byte) error {
+	return parseFields(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			return parseFields(b, func(num int, v uint64, _ []byte) error {
+				switch num {
+				case 1:
+					h.Params.MinSize = int(v)
+				case 2:
+					h.Params.AvgSize = int(v)
+				case 3:
+					h.Params.MaxSize = int(v)
+				}
+				return nil
+			})
+		case 2:
+			h.Variant = string(b)
+		case 3:
+			h.Normalization = int(v)
+		case 4:
+			h.GearID = v
+		case 5:
+			h.HashAlgorithm = string(b)
+		case 6:
+			h.Checksum = string(b)
+		case 7:
+			h.Encoding = string(b)
+		case 8:
+			h.HashSize = int(v)
+		}
+		return nil
+	})
+}
+
+// unmarshalProto decodes an entry with a hash of hashSize bytes
+func (e *Entry) unmarshalProto(data []byte, hashSize int) error {
+	return parseFields(data, func(num int, v uint64, b []byte) error {
+		switch num {
+		case 1:
+			e.Offset = int64(v)
+		case 2:
+			e.Length = int(v)
+		case 3:
+			if len(b) != hashSize {
+				return fmt.Errorf("%w: hash of %d bytes", ErrFormat, len(b))
+			}
+			copy(e.Hash[:], b)
+		case 4:
+			e.CRC = uint32(v)
+		}
+		return nil
+	})
+}
+
+// parseFields calls fn for each field in data with its number and either
+// its numeric value or its bytes, depending on the wire type
+func parseFields(data []byte, fn func(num int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return fmt.Errorf("%w: bad field key", ErrFormat)
+		}
+		data = data[n:]
+
+		var v uint64
+		var b []byte
+		switch key & 7 {
+		case wireVarint:
+			if v, n = binary.Uvarint(data); n <= 0 {
+				return fmt.Errorf("%w: bad varint", ErrFormat)
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return fmt.Errorf("%w: truncated", ErrFormat)
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return fmt.Errorf("%w: truncated", ErrFormat)
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || length > uint64(len(data)-n) {
+				return fmt.Errorf("%w: truncated", ErrFormat)
+			}
+			b, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return fmt.Errorf("%w: wire type %d", ErrFormat, key&7)
+		}
+
+		if err := fn(int(key>>3), v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendVarintField appends a varint field, omitting zero values as proto3
+// does
+func appendVarintField(buf []byte, num int, v uint64) []byte {
+	if v == 0 {
+		return buf
+	}
+	buf = binary.AppendUvarint(buf, uint64(num)<<3|wireVarint)
+	return binary.AppendUvarint(buf, v)
+}
+
+// appendBytesField appends a length-delimited field, omitting empty ones
+func appendBytesField(buf []byte, num int, b []byte) []byte {
+	if len(b) == 0 {
+		return buf
+	}
+	buf = binary.AppendUvarint(buf, uint64(num)<<3|wireBytes)
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
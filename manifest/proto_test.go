@@ -0,0 +1,113 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestProto(t *testing.T) {
+	m := newManifest(t, datagen.LCG(300*1024, 1))
+	m.Header.GearID = GearID(fastcdc.GearTable(1))
+
+	var decoded Manifest
+	if err := decoded.UnmarshalProto(m.MarshalProto()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Header != m.Header {
+		t.Errorf("expected header %+v, got %+v", m.Header, decoded.Header)
+	}
+	if len(decoded.Entries) != len(m.Entries) {
+		t.Fatalf("expected %d entries, got %d", len(m.Entries), len(decoded.Entries))
+	}
+	for i := range m.Entries {
+		if decoded.Entries[i] != m.Entries[i] {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, m.Entries[i], decoded.Entries[i])
+		}
+	}
+}
+
+func TestProtoWire(t *testing.T) {
+	m := &Manifest{
+		Header:  Header{Params: fastcdc.Params{MinSize: 1, AvgSize: 2, MaxSize: 300}, Variant: "v"},
+		Entries: []Entry{{Offset: 0, Length: 5, Hash: Hash{0xab}}},
+	}
+	hash := append([]byte{0xab}, make([]byte, 31)...)
+
+	// Hand-encoded per manifest.proto
+	expected := []byte{
+		0x0a, 12, // header
+		0x0a, 7, 0x08, 1, 0x10, 2, 0x18, 0xac, 0x02, // params
+		0x12, 1, 'v', // variant
+		0x12, 36, // chunk, offset 0 omitted
+		0x10, 5, // length
+		0x1a, 32, // hash
+	}
+	expected = append(expected, hash...)
+	if got := m.MarshalProto(); !bytes.Equal(got, expected) {
+		t.Fatalf("expected %x, got %x", expected, got)
+	}
+
+	// Unknown fields of all wire types are skipped
+	unknown := []byte{0x78, 1, 0x81, 0x01, 1, 2, 3, 4, 5, 6, 7, 8, 0x9a, 0x01, 1, 0, 0xa5, 0x01, 1, 2, 3, 4}
+	var decoded Manifest
+	if err := decoded.UnmarshalProto(append(unknown, expected...)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Header != m.Header || len(decoded.Entries) != 1 || decoded.Entries[0] != m.Entries[0] {
+		t.Errorf("expected %+v, got %+v", m, decoded)
+	}
+}
+
+func TestProtoErrors(t *testing.T) {
+	m := newManifest(t, datagen.LCG(100*1024, 2))
+	data := m.MarshalProto()
+
+	var decoded Manifest
+	for _, cut := range []int{1, len(data) / 2, len(data) - 1} {
+		if err := decoded.UnmarshalProto(data[:cut]); !errors.Is(err, ErrFormat) {
+			t.Errorf("truncated at %d: expected ErrFormat, got %v", cut, err)
+		}
+	}
+
+	// Chunks must be contiguous
+	gap := &Manifest{Entries: []Entry{{Offset: 0, Length: 10}, {Offset: 20, Length: 10}}}
+	if err := decoded.UnmarshalProto(gap.MarshalProto()); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+
+	// Checksums must be supported and in the header if chunks have them
+	for name, h := range map[string]Header{"unsupported": {Checksum: "md5"}, "missing": {}} {
+		bad := &Manifest{Header: h, Entries: []Entry{{Offset: 0, Length: 10, CRC: 1}}}
+		if err := decoded.UnmarshalProto(bad.MarshalProto()); !errors.Is(err, ErrFormat) {
+			t.Errorf("%s checksum: expected ErrFormat, got %v", name, err)
+		}
+	}
+}
+
+func TestProtoFieldOrder(t *testing.T) {
+	h := defaultHeader
+	h.HashSize = 16
+	m, err := New(bytes.NewReader(datagen.LCG(100*1024, 3)), h)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Move the header field, which comes first, to the end
+	data := m.MarshalProto()
+	length, n := binary.Uvarint(data[1:])
+	end := 1 + n + int(length)
+	reordered := append(bytes.Clone(data[end:]), data[:end]...)
+
+	var decoded Manifest
+	if err := decoded.UnmarshalProto(reordered); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decoded.Header != m.Header || len(decoded.Entries) != len(m.Entries) || decoded.Entries[1] != m.Entries[1] {
+		t.Errorf("expected %+v, got %+v", m, decoded)
+	}
+}
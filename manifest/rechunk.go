@@ -0,0 +1,59 @@
+package manifest
+
+import (
+	"io"
+	"sort"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// Rechunk returns the manifest of the edited object in r, in which the
+// oldLength bytes at offset in the object of m were replaced by newLength
+// bytes. Only the region around the edit is read and chunked: from the
+// entry whose cut point the edit may have moved, until a cut point after
+// the edit falls on an entry boundary of m, from where the entries of m
+// are reused. The result is the same as chunking the whole object, so a
+// small edit to a huge file does not cost a full pass. Options with
+// boundary policies that depend on the whole stream, such as
+// fastcdc.WithTarBoundaries, must not be used. m is not modified.
+func (m *Manifest) Rechunk(r io.ReaderAt, offset, oldLength, newLength int64, opts ...fastcdc.Option) (*Manifest, error) {
+	if offset < 0 || oldLength < 0 || newLength < 0 || offset+oldLength > m.Size() {
+		return nil, ErrRange
+	}
+	delta := newLength - oldLength
+	size := m.Size() + delta
+	end := offset + newLength
+
+	// A cut point depends on the byte after it, so an entry ending at the
+	// edit is chunked again too
+	first := sort.Search(len(m.Entries), func(i int) bool {
+		e := m.Entries[i]
+		return e.Offset+int64(e.Length) >= offset
+	})
+	start := m.Size()
+	if first < len(m.Entries) {
+		start = m.Entries[first].Offset
+	}
+
+	out := &Manifest{Header: m.Header}
+	out.Entries = append(out.Entries, m.Entries[:first]...)
+	c := fastcdc.NewChunkerAt(r, start, size-start, m.Header.Params, opts...)
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		out.Entries = append(out.Entries, m.Header.entry(chunk))
+
+		// Past the edit, a cut point on an old boundary resynchronizes
+		if cut := int64(chunk.Offset + chunk.Length); cut >= end && cut < size {
+			if i, ok := m.boundary(cut - delta); ok {
+				out.Entries = appendShifted(out.Entries, m.Entries[i:], delta)
+				return out, nil
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package manifest
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+// countingReaderAt counts the bytes read from it
+type countingReaderAt struct {
+	r *bytes.Reader
+	n int64
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, err := c.r.ReadAt(p, off)
+	c.n += int64(n)
+	return n, err
+}
+
+func TestRechunk(t *testing.T) {
+	old := datagen.LCG(4*1024*1024, 1)
+	m := newManifest(t, old)
+	boundary := m.Entries[100].Offset
+
+	for _, edit := range []struct {
+		name                      string
+		offset, oldLength, length int64
+	}{
+		{"insert", 2000000, 0, 1000},
+		{"delete", 1500000, 5000, 0},
+		{"replace", 3000000, 300, 300},
+		{"at start", 0, 10, 100},
+		{"at end", int64(len(old)) - 100, 100, 50},
+		{"at boundary", boundary, 0, 10},
+		{"before boundary", boundary - 10, 10, 0},
+	} {
+		data := slices.Concat(old[:edit.offset], datagen.LCG(int(edit.length), 2), old[edit.offset+edit.oldLength:])
+		r := &countingReaderAt{r: bytes.NewReader(data)}
+		got, err := m.Rechunk(r, edit.offset, edit.oldLength, edit.length)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", edit.name, err)
+		}
+		want := newManifest(t, data)
+		if !slices.Equal(got.Entries, want.Entries) {
+			t.Fatalf("%s: entries differ from chunking the whole object", edit.name)
+		}
+		if r.n > 1024*1024 {
+			t.Fatalf("%s: expected a small region read, got %d bytes", edit.name, r.n)
+		}
+	}
+
+	if _, err := m.Rechunk(bytes.NewReader(old), int64(len(old)), 1, 0); err != ErrRange {
+		t.Fatalf("expected ErrRange, got %v", err)
+	}
+}
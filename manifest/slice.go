@@ -0,0 +1,44 @@
+package manifest
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrRange is returned by Slice for a byte range outside the object
+var ErrRange = errors.New("manifest: range out of bounds")
+
+// Range is the run of entries covering a byte range. The range starts
+// Skip bytes into the first entry and ends Trim bytes before the end of
+// the last one.
+type Range struct {
+	Entries []Entry
+	Skip    int
+	Trim    int
+}
+
+// Slice returns the minimal run of entries covering length bytes starting
+// at offset. The entries share memory with m.
+func (m *Manifest) Slice(offset, length int64) (Range, error) {
+	if offset < 0 || length < 0 || offset+length > m.Size() {
+		return Range{}, ErrRange
+	}
+	if length == 0 {
+		return Range{}, nil
+	}
+
+	end := offset + length
+	first := sort.Search(len(m.Entries), func(i int) bool {
+		e := m.Entries[i]
+		return e.Offset+int64(e.Length) > offset
+	})
+	last := sort.Search(len(m.Entries), func(i int) bool {
+		e := m.Entries[i]
+		return e.Offset+int64(e.Length) >= end
+	})
+
+	r := Range{Entries: m.Entries[first : last+1]}
+	r.Skip = int(offset - m.Entries[first].Offset)
+	r.Trim = int(m.Entries[last].Offset + int64(m.Entries[last].Length) - end)
+	return r, nil
+}
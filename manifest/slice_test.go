@@ -0,0 +1,60 @@
+package manifest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestSlice(t *testing.T) {
+	data := datagen.LCG(256*1024, 1)
+	m, err := New(bytes.NewReader(data), defaultHeader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	e := m.Entries
+	cases := []struct {
+		offset, length int64
+	}{
+		{0, m.Size()},
+		{0, 1},
+		{m.Size() - 1, 1},
+		{e[3].Offset, int64(e[3].Length)},
+		{e[3].Offset + 1, int64(e[3].Length)},
+		{e[3].Offset - 1, 2},
+		{e[5].Offset + 100, e[9].Offset - e[5].Offset},
+	}
+	for _, tc := range cases {
+		r, err := m.Slice(tc.offset, tc.length)
+		if err != nil {
+			t.Fatalf("%d+%d: unexpected error: %v", tc.offset, tc.length, err)
+		}
+
+		// Reassembling the entries and trimming yields the range
+		var got []byte
+		for _, e := range r.Entries {
+			got = append(got, data[e.Offset:e.Offset+int64(e.Length)]...)
+		}
+		got = got[r.Skip : len(got)-r.Trim]
+		if !bytes.Equal(got, data[tc.offset:tc.offset+tc.length]) {
+			t.Errorf("%d+%d: expected range data, got %d bytes", tc.offset, tc.length, len(got))
+		}
+
+		// The run is minimal
+		first, last := r.Entries[0], r.Entries[len(r.Entries)-1]
+		if r.Skip >= first.Length || r.Trim >= last.Length {
+			t.Errorf("%d+%d: expected minimal run, got skip %d and trim %d", tc.offset, tc.length, r.Skip, r.Trim)
+		}
+	}
+
+	if r, err := m.Slice(100, 0); err != nil || len(r.Entries) != 0 {
+		t.Errorf("expected empty range, got %+v, %v", r, err)
+	}
+	for _, tc := range [][2]int64{{-1, 1}, {0, m.Size() + 1}, {m.Size(), 1}, {0, -1}} {
+		if _, err := m.Slice(tc[0], tc[1]); err != ErrRange {
+			t.Errorf("%d+%d: expected ErrRange, got %v", tc[0], tc[1], err)
+		}
+	}
+}
@@ -0,0 +1,253 @@
+package manifest
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// Writer encodes entries one at a time as they are produced, so that a
+// manifest never needs to be held in memory. Close must be called to
+// complete the encoding.
+type Writer struct {
+	cw     countWriter
+	h      Header
+	buf    []byte
+	offset int64  // Offset of the next entry
+	count  uint64 // Number of entries written
+	header bool   // Whether the header has been written
+}
+
+// NewWriter returns a Writer that writes the binary encoding to w, with
+// header h
+func NewWriter(w io.Writer, h Header) *Writer {
+	return &Writer{cw: countWriter{w: bufio.NewWriter(w)}, h: h}
+}
+
+// Add appends an entry, which must start where the previous one ended
+func (w *Writer) Add(e Entry) error {
+	if e.Offset != w.offset || e.Length <= 0 {
+		return fmt.Errorf("manifest: entry %d is not contiguous", w.count)
+	}
+	if w.writeHeader(); w.cw.err != nil {
+		return w.cw.err
+	}
+	w.buf = binary.AppendUvarint(w.buf[:0], uint64(e.Length))
+	w.cw.write(w.buf)
+	w.cw.write(e.Hash[:w.h.hashSize()])
+	if w.h.Checksum != "" {
+		w.cw.write(binary.LittleEndian.AppendUint32(w.buf[:0], e.CRC))
+	}
+
+	w.offset += int64(e.Length)
+	w.count++
+	return w.cw.err
+}
+
+// AddChunk appends an entry for a chunk, hashing its data
+func (w *Writer) AddChunk(c fastcdc.Chunk) error {
+	return w.Add(w.h.entry(c))
+}
+
+// Close ends the encoding and flushes it. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	w.writeHeader()
+	w.buf = binary.AppendUvarint(w.buf[:0], 0)
+	w.buf = binary.AppendUvarint(w.buf, w.count)
+	w.cw.write(w.buf)
+
+	if w.cw.err != nil {
+		return w.cw.err
+	}
+	return w.cw.w.Flush()
+}
+
+func (w *Writer) writeHeader() {
+	if !w.header {
+		version := uint64(Version)
+		switch {
+		case w.h.HashSize != 0:
+			version = Version4
+		case w.h.Checksum != "":
+			version = Version3
+		}
+		if err := w.h.checkEntries(); err != nil && w.cw.err == nil {
+			w.cw.err = err
+		}
+		w.cw.write(appendHeader(binary.AppendUvarint(magic[:], version), w.h))
+		w.header = true
+	}
+}
+
+// Reader decodes entries one at a time without loading the manifest
+type Reader struct {
+	// Strict makes the Reader reject unknown header fields with odd tags,
+	// which affect chunk boundaries or hashes, instead of skipping them
+	Strict bool
+
+	cr      countReader
+	h       Header
+	version uint64
+	offset  int64  // Offset of the next entry
+	count   uint64 // Number of entries read
+	header  bool   // Whether the header has been read
+	err     error  // Sticky error, io.EOF after the last entry
+}
+
+// NewReader returns a Reader that decodes the binary encoding from r. It
+// may read past the end of the encoding.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{cr: countReader{r: bufio.NewReader(r)}}
+}
+
+// Header returns the header, reading it if needed
+func (r *Reader) Header() (Header, error) {
+	if !r.header && r.err == nil {
+		r.err = r.readHeader()
+		r.header = r.err == nil
+	}
+	if r.header {
+		return r.h, nil
+	}
+	return Header{}, r.err
+}
+
+// Version returns the version of the encoding, reading the header if
+// needed
+func (r *Reader) Version() (int, error) {
+	if _, err := r.Header(); err != nil {
+		return 0, err
+	}
+	return int(r.version), nil
+}
+
+// Next returns the next entry, or io.EOF once the encoding has ended and
+// been checked for truncation
+func (r *Reader) Next() (Entry, error) {
+	if r.err == nil {
+		var e Entry
+		e, r.err = r.next()
+		if r.err == nil {
+			return e, nil
+		}
+	}
+	return Entry{}, r.err
+}
+
+func (r *Reader) next() (Entry, error) {
+	if _, err := r.Header(); err != nil {
+		return Entry{}, err
+	}
+
+	length, err := binary.ReadUvarint(&r.cr)
+	if err != nil {
+		return Entry{}, formatError(err)
+	}
+	if length == 0 {
+		count, err := binary.ReadUvarint(&r.cr)
+		if err != nil {
+			return Entry{}, formatError(err)
+		}
+		if count != r.count {
+			return Entry{}, fmt.Errorf("%w: expected %d entries, got %d", ErrFormat, count, r.count)
+		}
+		return Entry{}, io.EOF
+	}
+	if length > 1<<31 {
+		return Entry{}, fmt.Errorf("%w: chunk length %d", ErrFormat, length)
+	}
+
+	e := Entry{Offset: r.offset, Length: int(length)}
+	if _, err := io.ReadFull(&r.cr, e.Hash[:r.h.hashSize()]); err != nil {
+		return Entry{}, formatError(err)
+	}
+	if r.h.Checksum != "" {
+		var crc [4]byte
+		if _, err := io.ReadFull(&r.cr, crc[:]); err != nil {
+			return Entry{}, formatError(err)
+		}
+		e.CRC = binary.LittleEndian.Uint32(crc[:])
+	}
+	r.offset += int64(length)
+	r.count++
+	return e, nil
+}
+
+func (r *Reader) readHeader() error {
+	var head [len(magic)]byte
+	if _, err := io.ReadFull(&r.cr, head[:]); err != nil {
+		return formatError(err)
+	}
+	if head != magic {
+		return fmt.Errorf("%w: bad magic", ErrFormat)
+	}
+	version, err := binary.ReadUvarint(&r.cr)
+	if err != nil {
+		return formatError(err)
+	}
+	r.version = version
+	switch version {
+	case Version1:
+		return nil
+	case Version2, Version3, Version4:
+		if r.h, err = readHeader(&r.cr, r.Strict); err != nil {
+			return err
+		}
+		if version != Version4 && (version == Version2) != (r.h.Checksum == "") {
+			return fmt.Errorf("%w: checksum %q in version %d", ErrFormat, r.h.Checksum, version)
+		}
+		if (version == Version4) != (r.h.HashSize != 0) {
+			return fmt.Errorf("%w: hash size %d in version %d", ErrFormat, r.h.HashSize, version)
+		}
+		return r.h.checkEntries()
+	}
+	return fmt.Errorf("%w: unsupported version %d", ErrFormat, version)
+}
+
+// formatError reports a truncated encoding as ErrFormat
+func formatError(err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return fmt.Errorf("%w: truncated", ErrFormat)
+	}
+	return err
+}
+
+// countWriter counts bytes written and keeps the first error
+type countWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countWriter) write(p []byte) {
+	if cw.err != nil {
+		return
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+}
+
+// countReader counts bytes read, including single bytes for varints
+type countReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (cr *countReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (cr *countReader) ReadByte() (byte, error) {
+	b, err := cr.r.ReadByte()
+	if err == nil {
+		cr.n++
+	}
+	return b, err
+}
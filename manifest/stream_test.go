@@ -0,0 +1,110 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestWriterReader(t *testing.T) {
+	data := datagen.LCG(2*1024*1024, 1)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, defaultHeader)
+	c := fastcdc.NewChunker(bytes.NewReader(data))
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := w.AddChunk(chunk); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The streamed encoding matches that of the whole manifest
+	m, err := New(bytes.NewReader(data), defaultHeader)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var expected bytes.Buffer
+	if _, err := m.WriteTo(&expected); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected.Bytes()) {
+		t.Fatal("expected streamed encoding to match WriteTo")
+	}
+
+	r := NewReader(&buf)
+	for i := 0; ; i++ {
+		e, err := r.Next()
+		if err == io.EOF {
+			if i != len(m.Entries) {
+				t.Fatalf("expected %d entries, got %d", len(m.Entries), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if e != m.Entries[i] {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, m.Entries[i], e)
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF again, got %v", err)
+	}
+}
+
+func TestWriterEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, defaultHeader).Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := NewReader(&buf).Next(); err != io.EOF {
+		t.Errorf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestReaderError(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte("FCDM\x01\x05")))
+	if _, err := r.Next(); !errors.Is(err, ErrFormat) {
+		t.Fatalf("expected ErrFormat, got %v", err)
+	}
+	if _, err := r.Next(); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat to persist, got %v", err)
+	}
+}
+
+func TestWriterContiguous(t *testing.T) {
+	w := NewWriter(io.Discard, defaultHeader)
+	if err := w.Add(Entry{Offset: 0, Length: 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.Add(Entry{Offset: 5, Length: 10}); err == nil {
+		t.Error("expected error for overlapping entry")
+	}
+}
+
+func TestWriterChecksum(t *testing.T) {
+	// Checksums the Reader does not support are not written
+	h := defaultHeader
+	h.Checksum = "md5"
+	if err := NewWriter(io.Discard, h).Add(Entry{Offset: 0, Length: 10}); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+	var buf bytes.Buffer
+	if err := NewWriter(&buf, h).Close(); !errors.Is(err, ErrFormat) || buf.Len() != 0 {
+		t.Errorf("expected ErrFormat and no output, got %v and %d bytes", err, buf.Len())
+	}
+}
@@ -0,0 +1,32 @@
+package manifest
+
+import "io"
+
+// Upgrade decodes a manifest of any supported version from r and writes
+// it to w in the current version, one entry at a time. Version 1
+// manifests do not record how they were chunked, so they get header h.
+// Newer versions keep their own header.
+func Upgrade(w io.Writer, r io.Reader, h Header) error {
+	mr := NewReader(r)
+	version, err := mr.Version()
+	if err != nil {
+		return err
+	}
+	if version != Version1 {
+		h = mr.h
+	}
+
+	mw := NewWriter(w, h)
+	for {
+		e, err := mr.Next()
+		if err == io.EOF {
+			return mw.Close()
+		}
+		if err != nil {
+			return err
+		}
+		if err := mw.Add(e); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+// encodeV1 encodes entries in version 1, which has no header
+func encodeV1(entries []Entry) []byte {
+	enc := binary.AppendUvarint(magic[:], Version1)
+	for _, e := range entries {
+		enc = binary.AppendUvarint(enc, uint64(e.Length))
+		enc = append(enc, e.Hash[:]...)
+	}
+	enc = binary.AppendUvarint(enc, 0)
+	return binary.AppendUvarint(enc, uint64(len(entries)))
+}
+
+func TestUpgrade(t *testing.T) {
+	m := newManifest(t, datagen.LCG(200*1024, 1))
+
+	var v1 Manifest
+	if _, err := v1.ReadFrom(bytes.NewReader(encodeV1(m.Entries))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v1.Header != (Header{}) || len(v1.Entries) != len(m.Entries) {
+		t.Fatalf("expected empty header and %d entries, got %+v and %d", len(m.Entries), v1.Header, len(v1.Entries))
+	}
+
+	var upgraded bytes.Buffer
+	if err := Upgrade(&upgraded, bytes.NewReader(encodeV1(m.Entries)), defaultHeader); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var current bytes.Buffer
+	if _, err := m.WriteTo(&current); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(upgraded.Bytes(), current.Bytes()) {
+		t.Fatal("expected upgraded encoding to match current version")
+	}
+
+	// Current versions keep their header
+	var again bytes.Buffer
+	if err := Upgrade(&again, bytes.NewReader(current.Bytes()), Header{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(again.Bytes(), current.Bytes()) {
+		t.Error("expected current version to be unchanged")
+	}
+
+	if err := Upgrade(&again, bytes.NewReader([]byte("FCDM\x09")), defaultHeader); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat for unknown version, got %v", err)
+	}
+}
+
+func TestStrict(t *testing.T) {
+	encode := func(tag byte) []byte {
+		enc := binary.AppendUvarint(magic[:], Version)
+		enc = append(enc, tag, 1, 'x')
+		return append(appendHeader(enc, defaultHeader), 0, 0)
+	}
+
+	for _, tc := range []struct {
+		tag    byte
+		strict bool
+		ok     bool
+	}{
+		{100, false, true},
+		{100, true, true},
+		{101, false, true},
+		{101, true, false},
+	} {
+		r := NewReader(bytes.NewReader(encode(tc.tag)))
+		r.Strict = tc.strict
+		_, err := r.Header()
+		if tc.ok && err != nil {
+			t.Errorf("tag %d, strict %v: unexpected error: %v", tc.tag, tc.strict, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrFormat) {
+			t.Errorf("tag %d, strict %v: expected ErrFormat, got %v", tc.tag, tc.strict, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package manifest
+
+import (
+	"fmt"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// Verify chunks r with the params of the header of m and checks that it
+// yields exactly the entries of m, for confirming that what a manifest
+// describes equals the source on disk. A custom gear table must be given
+// in opts. It returns an error wrapping ErrIncompatible if opts chunk
+// differently from the header of m, and one wrapping ErrChecksum for the
+// first chunk that differs.
+func Verify(m *Manifest, r io.Reader, opts ...fastcdc.Option) error {
+	c := fastcdc.NewChunker(r, append([]fastcdc.Option{fastcdc.WithParams(m.Header.Params)}, opts...)...)
+	if err := m.Header.checkConfig(c.Config()); err != nil {
+		return err
+	}
+	for i := 0; ; i++ {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			if i < len(m.Entries) {
+				return fmt.Errorf("%w: source ends at %d, expected %d bytes", ErrChecksum, m.Entries[i].Offset, m.Size())
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if i == len(m.Entries) {
+			return fmt.Errorf("%w: source continues past %d bytes", ErrChecksum, m.Size())
+		}
+		if e := m.Entries[i]; m.Header.entry(chunk) != e {
+			return fmt.Errorf("%w: chunk %d at %d", ErrChecksum, i, e.Offset)
+		}
+	}
+}
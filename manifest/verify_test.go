@@ -0,0 +1,37 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestVerify(t *testing.T) {
+	data := datagen.LCG(300*1024, 5)
+	m := newManifest(t, data)
+	if err := Verify(m, bytes.NewReader(data)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Chunking with other settings than the header records is refused
+	for name, opt := range map[string]fastcdc.Option{
+		"two-byte": fastcdc.WithTwoByteScan(),
+		"gear":     fastcdc.WithGearTable(fastcdc.GearTable(3)),
+		"params":   fastcdc.WithParams(fastcdc.Params{MinSize: 1024, AvgSize: 4096, MaxSize: 16384}),
+	} {
+		if err := Verify(m, bytes.NewReader(data), opt); !errors.Is(err, ErrIncompatible) {
+			t.Errorf("%s: expected ErrIncompatible, got %v", name, err)
+		}
+	}
+
+	changed := bytes.Clone(data)
+	changed[150*1024]++
+	for _, source := range [][]byte{changed, data[:len(data)-1], append(bytes.Clone(data), 0)} {
+		if err := Verify(m, bytes.NewReader(source)); !errors.Is(err, ErrChecksum) {
+			t.Errorf("expected ErrChecksum for %d byte source, got %v", len(source), err)
+		}
+	}
+}
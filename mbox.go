@@ -0,0 +1,32 @@
+package fastcdc
+
+import (
+	"bytes"
+)
+
+// Messages in mbox files start with a "From " line
+var mboxSeparator = []byte("\nFrom ")
+
+// WithMboxBoundaries forces a cut at the start of every message in an mbox
+// stream, so identical messages dedupe across mailboxes regardless of what
+// precedes them. Messages larger than the maximum size are chunked as
+// usual. Maildir needs no special handling as it stores one message per
+// file.
+func WithMboxBoundaries() Option {
+	return WithBoundaryPolicy(mboxPolicy{})
+}
+
+type mboxPolicy struct{}
+
+// Hint returns the position of the first message start in data after its
+// first byte, or 0 if there is none
+func (mboxPolicy) Hint(offset int, data []byte) int {
+	if i := bytes.Index(data, mboxSeparator); i >= 0 {
+		return i + 1
+	}
+	return 0
+}
+
+func (mboxPolicy) Adjust(offset int, data []byte, cutPoint int) int {
+	return cutPoint
+}
@@ -0,0 +1,49 @@
+package fastcdc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestMboxBoundaries(t *testing.T) {
+	// Messages of 100 bytes to 64 KiB of pseudorandom text
+	random := make([]byte, 4*miB)
+	fillLCG(random, 42)
+	for i, b := range random {
+		random[i] = 'a' + b%26
+	}
+
+	var data []byte
+	starts := map[int]bool{}
+	for i := 0; i < 200; i++ {
+		size := 100 + (i*7919)%(64*kiB)
+		starts[len(data)] = true
+		data = append(data, fmt.Sprintf("From sender%d@example.com Mon Jan 1 00:00:00 2024\n", i)...)
+		data = append(data, random[i*kiB:i*kiB+size]...)
+		data = append(data, '\n')
+	}
+
+	chunker := NewChunker(bytes.NewReader(data), WithMboxBoundaries())
+	cuts := map[int]bool{}
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		if len(chunk.Data) > chunker.maxSize {
+			t.Errorf("chunk at %d exceeds max size: %d", chunk.Offset, len(chunk.Data))
+		}
+		cuts[chunk.Offset] = true
+	}
+
+	for start := range starts {
+		if !cuts[start] {
+			t.Errorf("expected a cut at message start %d", start)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package multipart uploads large objects in parts that end on chunk
+// boundaries, so that a later version of the object can reuse unchanged
+// parts by copying them instead of uploading them again, as with S3
+// multipart uploads and UploadPartCopy.
+package multipart
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+const (
+	MinPartSize = 5 * 1024 * 1024        // Minimum size of all but the last part
+	MaxPartSize = 5 * 1024 * 1024 * 1024 // Maximum size of a part
+	MaxParts    = 10000                  // Maximum number of parts in an upload
+)
+
+// ErrTooManyParts is returned when an object would need more than MaxParts
+// parts with the given part size
+var ErrTooManyParts = errors.New("multipart: too many parts")
+
+// Part describes one part of an upload and the chunks it consists of.
+// Storing the parts of an upload alongside the object allows the next
+// upload to find parts it can copy.
+type Part struct {
+	Number     int      `json:"number"`      // Part number, starting from 1
+	Offset     int64    `json:"offset"`      // Offset of the part in the object
+	Length     int64    `json:"length"`      // Length of the part
+	FirstChunk int      `json:"first_chunk"` // Index of the first chunk in the part
+	Chunks     int      `json:"chunks"`      // Number of chunks in the part
+	Digest     [32]byte `json:"digest"`      // SHA-256 over the SHA-256 of each chunk
+	ETag       string   `json:"etag"`        // ETag returned by the Uploader
+	Copied     bool     `json:"copied"`      // Whether the part was copied from a previous upload
+}
+
+// Uploader uploads the parts of one object. CopyPart copies a part of the
+// previous version of the object, described by src, as the given part
+// number, and does not need the data.
+type Uploader interface {
+	UploadPart(number int, data []byte) (etag string, err error)
+	CopyPart(number int, src Part) (etag string, err error)
+}
+
+// Upload chunks r, groups the chunks into parts of about partSize bytes
+// and uploads them with u. Parts that have the same digest as one of prev,
+// the parts of a previous upload, are copied instead. Options are passed
+// to the chunker.
+//
+// Part boundaries are chosen from the chunk hashes, so after an edit the
+// parts resynchronize with the previous upload as the chunks do. Except
+// for the last one, parts are at least the larger of partSize/2 and
+// MinPartSize, and at most the smaller of 2*partSize and MaxPartSize.
+func Upload(r io.Reader, u Uploader, partSize int64, prev []Part, opts ...fastcdc.Option) ([]Part, error) {
+	minSize := max(partSize/2, MinPartSize)
+	maxSize := min(max(2*partSize, minSize), MaxPartSize)
+
+	previous := make(map[[32]byte]Part, len(prev))
+	for _, p := range prev {
+		previous[p.Digest] = p
+	}
+
+	var (
+		parts  []Part
+		part   = Part{Number: 1}
+		data   []byte
+		hashes = sha256.New()
+		index  int
+	)
+
+	flush := func() error {
+		if len(parts) == MaxParts {
+			return ErrTooManyParts
+		}
+		copy(part.Digest[:], hashes.Sum(nil))
+		var err error
+		if src, ok := previous[part.Digest]; ok && src.Length == part.Length {
+			part.ETag, err = u.CopyPart(part.Number, src)
+			part.Copied = true
+		} else {
+			part.ETag, err = u.UploadPart(part.Number, data)
+		}
+		if err != nil {
+			return err
+		}
+
+		parts = append(parts, part)
+		part = Part{Number: part.Number + 1, Offset: part.Offset + part.Length, FirstChunk: index}
+		data = data[:0]
+		hashes.Reset()
+		return nil
+	}
+
+	c := fastcdc.NewChunker(r, opts...)
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if part.Chunks > 0 && part.Length+int64(chunk.Length) > maxSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+
+		sum := sha256.Sum256(chunk.Data)
+		hashes.Write(sum[:])
+		data = append(data, chunk.Data...)
+		part.Length += int64(chunk.Length)
+		part.Chunks++
+		index++
+
+		// Past the minimum size, end the part after a chunk with probability
+		// proportional to its length, so parts average about partSize
+		if part.Length >= minSize &&
+			binary.BigEndian.Uint64(sum[:8])%uint64(max(partSize-minSize, 1)) < uint64(chunk.Length) {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// The last part may be small, and an empty object still has one part
+	if part.Chunks > 0 || len(parts) == 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+	return parts, nil
+}
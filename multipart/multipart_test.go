@@ -0,0 +1,108 @@
+package multipart
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+// memUploader assembles an object in memory, copying parts from prev
+type memUploader struct {
+	prev   []byte
+	parts  map[int][]byte
+	copied int
+}
+
+func (u *memUploader) UploadPart(number int, data []byte) (string, error) {
+	u.parts[number] = bytes.Clone(data)
+	return "upload", nil
+}
+
+func (u *memUploader) CopyPart(number int, src Part) (string, error) {
+	u.parts[number] = u.prev[src.Offset : src.Offset+src.Length]
+	u.copied++
+	return "copy", nil
+}
+
+func (u *memUploader) object() []byte {
+	var obj []byte
+	for i := 1; i <= len(u.parts); i++ {
+		obj = append(obj, u.parts[i]...)
+	}
+	return obj
+}
+
+func TestUpload(t *testing.T) {
+	const partSize = 8 * 1024 * 1024
+	v1 := datagen.LCG(100*1024*1024, 1)
+
+	u := &memUploader{parts: map[int][]byte{}}
+	parts, err := Upload(bytes.NewReader(v1), u, partSize, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(u.object(), v1) {
+		t.Fatal("expected uploaded object to match input")
+	}
+
+	var offset int64
+	chunks := 0
+	for i, p := range parts {
+		if p.Number != i+1 || p.Offset != offset || p.FirstChunk != chunks {
+			t.Fatalf("part %d: unexpected number %d, offset %d or first chunk %d", i, p.Number, p.Offset, p.FirstChunk)
+		}
+		if i < len(parts)-1 && (p.Length < partSize/2 || p.Length > 2*partSize) {
+			t.Errorf("part %d: expected length within limits, got %d", i, p.Length)
+		}
+		offset += p.Length
+		chunks += p.Chunks
+	}
+	if n := len(parts); n < 6 || n > 25 {
+		t.Errorf("expected about %d parts, got %d", len(v1)/partSize, n)
+	}
+
+	// An insertion near the middle only changes the parts around it
+	v2 := datagen.Shifted(v1, len(v1)/2, 1000, 2)
+	u2 := &memUploader{prev: v1, parts: map[int][]byte{}}
+	parts2, err := Upload(bytes.NewReader(v2), u2, partSize, parts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(u2.object(), v2) {
+		t.Fatal("expected uploaded object to match edited input")
+	}
+	if u2.copied < len(parts2)-2 {
+		t.Errorf("expected all but 2 of %d parts to be copied, got %d", len(parts2), u2.copied)
+	}
+}
+
+func TestUploadSmall(t *testing.T) {
+	for _, size := range []int{0, 1000, MinPartSize + 1} {
+		u := &memUploader{parts: map[int][]byte{}}
+		data := datagen.LCG(size, 3)
+		parts, err := Upload(bytes.NewReader(data), u, MinPartSize, nil)
+		if err != nil {
+			t.Fatalf("size %d: unexpected error: %v", size, err)
+		}
+		if len(parts) != 1 || parts[0].Length != int64(size) {
+			t.Errorf("size %d: expected one part of full length, got %v", size, parts)
+		}
+		if !bytes.Equal(u.object(), data) {
+			t.Errorf("size %d: expected uploaded object to match input", size)
+		}
+	}
+}
+
+type failUploader struct{}
+
+func (failUploader) UploadPart(int, []byte) (string, error) { return "", errors.New("upload failed") }
+func (failUploader) CopyPart(int, Part) (string, error)     { return "", errors.New("copy failed") }
+
+func TestUploadError(t *testing.T) {
+	_, err := Upload(bytes.NewReader(datagen.LCG(1000, 4)), failUploader{}, MinPartSize, nil)
+	if err == nil || err.Error() != "upload failed" {
+		t.Errorf("expected upload error, got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package oci chunks OCI and Docker image layers for deduplication.
+// Layers are gzip-compressed tar archives, and since compression spreads
+// any change across the rest of the stream, chunking them as stored finds
+// almost nothing in common between image versions. Layers are therefore
+// decompressed and chunked at tar member boundaries.
+package oci
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/manifest"
+)
+
+// ErrCompression is returned for layers compressed other than with gzip
+var ErrCompression = errors.New("oci: unsupported layer compression")
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Layer is the manifest of an image layer and the digests identifying it
+type Layer struct {
+	Digest   string // Digest of the layer blob as stored, "sha256:..."
+	DiffID   string // Digest of the uncompressed tar archive
+	Manifest *manifest.Manifest
+}
+
+// ChunkLayer reads a layer blob, gzip-compressed or not, and returns the
+// manifest of the uncompressed archive with header h, recording gzip in
+// its Encoding if the blob was compressed. Manifests of layers
+// are keyed by Digest in image manifests and by DiffID in image configs.
+func ChunkLayer(r io.Reader, h manifest.Header, opts ...fastcdc.Option) (*Layer, error) {
+	blobHash := sha256.New()
+	br := bufio.NewReader(io.TeeReader(r, blobHash))
+	head, _ := br.Peek(len(zstdMagic))
+
+	var tr io.Reader = br
+	switch {
+	case bytes.HasPrefix(head, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		tr = zr
+		h.Encoding = fastcdc.EncodingGzip
+	case bytes.HasPrefix(head, zstdMagic):
+		return nil, ErrCompression
+	}
+
+	diffHash := sha256.New()
+	opts = append([]fastcdc.Option{fastcdc.WithTarBoundaries()}, opts...)
+	m, err := manifest.New(io.TeeReader(tr, diffHash), h, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Any data after the compressed stream is part of the blob
+	if _, err := io.Copy(io.Discard, br); err != nil {
+		return nil, err
+	}
+	return &Layer{Digest: digest(blobHash), DiffID: digest(diffHash), Manifest: m}, nil
+}
+
+func digest(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
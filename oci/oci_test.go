@@ -0,0 +1,91 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+	"github.com/jokkebk/go-fastcdc/manifest"
+)
+
+// layer returns a gzipped tar layer and the tar archive in it
+func layer(t *testing.T, files [][]byte, mtime time.Time) ([]byte, []byte) {
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	for i, data := range files {
+		hdr := &tar.Header{Name: fmt.Sprintf("usr/lib/file%d", i), Mode: 0644, Size: int64(len(data)), ModTime: mtime}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(data)
+	}
+	tw.Close()
+
+	var blob bytes.Buffer
+	zw := gzip.NewWriter(&blob)
+	zw.Write(archive.Bytes())
+	zw.Close()
+	return blob.Bytes(), archive.Bytes()
+}
+
+func TestChunkLayer(t *testing.T) {
+	random := datagen.LCG(2<<20, 1)
+	var files [][]byte
+	for i := range 30 {
+		files = append(files, random[i<<16:i<<16+(i*7919)%(100<<10)])
+	}
+	blob, archive := layer(t, files, time.Unix(1e9, 0))
+
+	h := manifest.DefaultHeader(fastcdc.DefaultParams)
+	l, err := ChunkLayer(bytes.NewReader(blob), h)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	blobSum, archiveSum := sha256.Sum256(blob), sha256.Sum256(archive)
+	if l.Digest != "sha256:"+hex.EncodeToString(blobSum[:]) || l.DiffID != "sha256:"+hex.EncodeToString(archiveSum[:]) {
+		t.Errorf("unexpected digests %s and %s", l.Digest, l.DiffID)
+	}
+	if l.Manifest.Header.Encoding != fastcdc.EncodingGzip {
+		t.Errorf("expected encoding %q, got %q", fastcdc.EncodingGzip, l.Manifest.Header.Encoding)
+	}
+	if err := manifest.Verify(l.Manifest, bytes.NewReader(archive), fastcdc.WithTarBoundaries()); err != nil {
+		t.Fatalf("expected manifest of the archive, got %v", err)
+	}
+
+	// A rebuilt layer with one changed file shares almost all chunks
+	files[3] = random[:len(files[3])]
+	blob2, _ := layer(t, files, time.Unix(2e9, 0))
+	l2, err := ChunkLayer(bytes.NewReader(blob2), h)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seen := map[manifest.Hash]bool{}
+	for _, e := range l.Manifest.Entries {
+		seen[e.Hash] = true
+	}
+	shared := int64(0)
+	for _, e := range l2.Manifest.Entries {
+		if seen[e.Hash] {
+			shared += int64(e.Length)
+		}
+	}
+	if size := l2.Manifest.Size(); shared < size*9/10 {
+		t.Errorf("expected most of %d bytes shared, got %d", size, shared)
+	}
+
+	// Uncompressed layers are chunked as is, and zstd is not supported
+	if l, err := ChunkLayer(bytes.NewReader(archive), h); err != nil || l.Digest != l.DiffID || l.Manifest.Header.Encoding != fastcdc.EncodingNone {
+		t.Errorf("expected uncompressed layer with equal digests and no encoding, got %v", err)
+	}
+	if _, err := ChunkLayer(bytes.NewReader([]byte{0x28, 0xb5, 0x2f, 0xfd, 0}), h); !errors.Is(err, ErrCompression) {
+		t.Errorf("expected ErrCompression, got %v", err)
+	}
+}
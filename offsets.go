@@ -0,0 +1,209 @@
+package fastcdc
+
+import (
+	"io"
+)
+
+// OffsetChunker finds chunk boundaries without returning chunk data, for
+// uses like signature generation that only need the boundary list. Since
+// the first minSize bytes of a chunk never affect its cut point, they are
+// skipped instead of read when the input allows it, and only the scan
+// window of the current chunk is buffered. Chunks have Data set to nil.
+type OffsetChunker struct {
+	readerAt io.ReaderAt // Random access input, or
+	reader   io.Reader   // sequential input
+
+	size   int64 // Total size of the input, -1 until known
+	offset int64 // Offset of next chunk
+	pos    int64 // Position of sequential reader
+	read   int64 // Bytes read from input
+
+	c      Chunker // Params and gear table
+	buf    []byte  // Current chunk from minSize on, plus a byte lookahead
+	filled int     // End of data in buf for the previous chunk
+	cut    int     // Cut point of the previous chunk
+}
+
+// NewOffsetChunker returns a chunker for the first size bytes of reader.
+// Of the options, only WithGearTable applies.
+func NewOffsetChunker(reader io.ReaderAt, size int64, p Params, opts ...Option) *OffsetChunker {
+	o := &OffsetChunker{
+		readerAt: reader,
+		size:     size,
+		buf:      make([]byte, p.MaxSize+1),
+	}
+	o.c.setParams(p)
+	for _, opt := range opts {
+		opt(&o.c)
+	}
+	return o
+}
+
+// NewStreamOffsetChunker returns a chunker for a sequential reader. If the
+// reader is an io.ReadSeeker, skipped bytes are seeked over; otherwise they
+// are read into the unused start of the buffer and discarded. Of the
+// options, only WithGearTable applies.
+func NewStreamOffsetChunker(reader io.Reader, p Params, opts ...Option) *OffsetChunker {
+	if rs, ok := reader.(io.ReadSeeker); ok {
+		if r, size, err := newSeekReaderAt(rs); err == nil {
+			return NewOffsetChunker(r, size, p, opts...)
+		}
+	}
+
+	o := NewOffsetChunker(nil, -1, p, opts...)
+	o.reader = reader
+	return o
+}
+
+// Next returns the next chunk and io.EOF after the last one. Boundaries are
+// the same as those of a Chunker with the same params.
+func (o *OffsetChunker) Next() (Chunk, error) {
+	c := &o.c
+	if o.size >= 0 && o.offset >= o.size {
+		return Chunk{}, io.EOF
+	}
+
+	// Reuse bytes read past the previous cut point
+	filled := c.minSize
+	if have := o.filled - o.cut; have > c.minSize {
+		copy(o.buf[c.minSize:], o.buf[o.cut+c.minSize:o.filled])
+		filled = have
+	}
+
+	// Read in blocks of half the minimum size, so that on average three
+	// quarters of the next chunk's minimum size can be skipped
+	block := max(c.minSize/2, 512)
+	i, fp := c.minSize, uint64(0)
+	var cutPoint int
+	var reason CutReason
+	for {
+		if limit := o.limit(); filled < limit {
+			n, err := o.fill(filled, min(filled+block, limit))
+			if err != nil {
+				return Chunk{}, err
+			}
+			filled += n
+		}
+
+		limit := o.limit()
+		if limit == 0 {
+			return Chunk{}, io.EOF
+		}
+		if limit <= c.minSize {
+			cutPoint, reason = limit, CutEOF
+			break
+		}
+
+		i, reason, fp = c.scan(o.buf[:filled], i, fp)
+		if reason != CutEOF {
+			cutPoint = i
+			break
+		}
+		if filled == limit {
+			cutPoint = filled
+			break
+		}
+	}
+
+	// Look a byte ahead to tell if a chunk ending at the data is final
+	if o.size < 0 && cutPoint == filled {
+		n, err := o.fill(filled, filled+1)
+		if err != nil {
+			return Chunk{}, err
+		}
+		filled += n
+	}
+
+	chunk := Chunk{
+		Offset: int(o.offset),
+		Length: cutPoint,
+		Reason: reason,
+		Final:  o.offset+int64(cutPoint) == o.size,
+	}
+	o.offset += int64(cutPoint)
+	o.filled, o.cut = filled, cutPoint
+	return chunk, nil
+}
+
+// BytesRead returns the number of bytes read from the input so far
+func (o *OffsetChunker) BytesRead() int64 {
+	return o.read
+}
+
+// limit returns the number of bytes the current chunk can have at most
+func (o *OffsetChunker) limit() int {
+	if o.size < 0 {
+		return o.c.maxSize
+	}
+	return int(min(int64(o.c.maxSize), o.size-o.offset))
+}
+
+// fill reads positions from up to to of the current chunk into buf. It
+// returns fewer bytes only at the end of a sequential input, whose size is
+// then known.
+func (o *OffsetChunker) fill(from, to int) (int, error) {
+	at := o.offset + int64(from)
+	if o.readerAt != nil {
+		n, err := o.readerAt.ReadAt(o.buf[from:to], at)
+		o.read += int64(n)
+		if n < to-from {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return n, err
+		}
+		return n, nil
+	}
+
+	// Skip to the position, using the unused start of buf as scratch
+	for o.pos < at {
+		n, err := io.ReadFull(o.reader, o.buf[:min(at-o.pos, int64(o.c.minSize))])
+		o.pos += int64(n)
+		o.read += int64(n)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			o.size = o.pos
+			return 0, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := io.ReadFull(o.reader, o.buf[from:to])
+	o.pos += int64(n)
+	o.read += int64(n)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		o.size = o.pos
+		err = nil
+	}
+	return n, err
+}
+
+// seekReaderAt implements io.ReaderAt by seeking, relative to where the
+// reader was positioned initially
+type seekReaderAt struct {
+	rs   io.ReadSeeker
+	base int64
+}
+
+func newSeekReaderAt(rs io.ReadSeeker) (*seekReaderAt, int64, error) {
+	base, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, err
+	}
+	end, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, err := rs.Seek(base, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	return &seekReaderAt{rs: rs, base: base}, end - base, nil
+}
+
+func (r *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if _, err := r.rs.Seek(r.base+off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(r.rs, p)
+}
@@ -0,0 +1,111 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestOffsetChunker(t *testing.T) {
+	data := make([]byte, 4*miB+123)
+	fillLCG(data, 42)
+
+	for _, p := range []Params{DefaultParams, {MinSize: 2 * kiB, AvgSize: 8 * kiB, MaxSize: 12 * kiB}} {
+		expected := Rechunk(data, p)
+		o := NewOffsetChunker(bytes.NewReader(data), int64(len(data)), p)
+
+		var chunks []Chunk
+		for {
+			chunk, err := o.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("error getting next chunk: %v", err)
+			}
+			chunks = append(chunks, chunk)
+		}
+
+		if len(chunks) != len(expected) {
+			t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+		}
+		for i, chunk := range chunks {
+			e := expected[i]
+			if chunk.Offset != e.Offset || chunk.Length != e.Length || chunk.Reason != e.Reason || chunk.Final != e.Final {
+				t.Fatalf("expected chunk %+v at index %d, got %+v", e, i, chunk)
+			}
+			if chunk.Data != nil {
+				t.Fatalf("expected no data in chunk %d", i)
+			}
+		}
+
+		if err := Validate(chunks, int64(len(data)), p); err != nil {
+			t.Errorf("offsets-only chunks are not valid: %v", err)
+		}
+		if o.BytesRead() > int64(len(data))*9/10 {
+			t.Errorf("expected to skip at least 10%% of input, read %d of %d bytes", o.BytesRead(), len(data))
+		}
+	}
+}
+
+func TestOffsetChunkerShortRead(t *testing.T) {
+	data := make([]byte, 64*kiB)
+	o := NewOffsetChunker(bytes.NewReader(data), 128*kiB, DefaultParams)
+
+	var err error
+	for err == nil {
+		_, err = o.Next()
+	}
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("expected io.ErrUnexpectedEOF for truncated input, got %v", err)
+	}
+}
+
+// readerOnly hides all methods but Read
+type readerOnly struct {
+	io.Reader
+}
+
+func TestStreamOffsetChunker(t *testing.T) {
+	data := make([]byte, 4*miB+123)
+	fillLCG(data, 42)
+
+	inputs := map[string][]byte{
+		"data":   data,
+		"maxEnd": make([]byte, 3*DefaultParams.MaxSize),
+		"short":  data[:1000],
+		"empty":  nil,
+	}
+
+	for name, input := range inputs {
+		expected := Rechunk(input, DefaultParams)
+		for _, seekable := range []bool{false, true} {
+			var r io.Reader = bytes.NewReader(input)
+			if !seekable {
+				r = readerOnly{r}
+			}
+			o := NewStreamOffsetChunker(r, DefaultParams)
+
+			for i := 0; ; i++ {
+				chunk, err := o.Next()
+				if err == io.EOF {
+					if i != len(expected) {
+						t.Errorf("%s: expected %d chunks, got %d", name, len(expected), i)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: error getting next chunk: %v", name, err)
+				}
+				e := expected[i]
+				if chunk.Offset != e.Offset || chunk.Length != e.Length || chunk.Reason != e.Reason || chunk.Final != e.Final {
+					t.Fatalf("%s: expected chunk %+v at index %d, got %+v", name, e, i, chunk)
+				}
+			}
+
+			if seekable && len(input) > 1*miB && o.BytesRead() > int64(len(input))*9/10 {
+				t.Errorf("%s: expected to skip at least 10%% of input, read %d of %d bytes", name, o.BytesRead(), len(input))
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package fastcdc
+
+import "bytes"
+
+// Ownership tells whether the data of returned chunks may be retained
+type Ownership int
+
+const (
+	// Borrow returns slices of the internal buffer, which are only valid
+	// until the next call to Next. This is the default, and avoids a copy
+	// per chunk for callers that hash or write out chunks right away.
+	Borrow Ownership = iota
+
+	// Copy returns Data and Sum in memory of their own, safe to retain
+	Copy
+)
+
+// WithOwnership sets whether chunks borrow the internal buffer or own a
+// copy of their data
+func WithOwnership(o Ownership) Option {
+	return func(c *Chunker) {
+		c.ownership = o
+	}
+}
+
+// Clone returns a copy of the chunk that does not share memory with a
+// Chunker, for retaining a borrowed chunk past the next call to Next
+func (c Chunk) Clone() Chunk {
+	c.Data = bytes.Clone(c.Data)
+	c.Sum = bytes.Clone(c.Sum)
+	return c
+}
@@ -0,0 +1,45 @@
+package fastcdc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+)
+
+func TestOwnership(t *testing.T) {
+	data := make([]byte, 256*kiB)
+	fillLCG(data, 5)
+
+	// Retained chunks keep their data and sums across calls to Next
+	chunker := NewChunker(bytes.NewReader(data), WithOwnership(Copy), WithHash(sha256.New))
+	var chunks []Chunk
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		chunks = append(chunks, chunk)
+	}
+	for _, chunk := range chunks {
+		sum := sha256.Sum256(data[chunk.Offset : chunk.Offset+chunk.Length])
+		if !bytes.Equal(chunk.Data, data[chunk.Offset:chunk.Offset+chunk.Length]) || !bytes.Equal(chunk.Sum, sum[:]) {
+			t.Fatalf("chunk at %d changed after later calls to Next", chunk.Offset)
+		}
+	}
+
+	// Borrowed chunks share the buffer, unless cloned
+	chunker = NewChunker(bytes.NewReader(data))
+	first, _ := chunker.Next()
+	clone := first.Clone()
+	second, _ := chunker.Next()
+	if &first.Data[0] != &chunker.buf[0] || &second.Data[0] != &chunker.buf[first.Length] {
+		t.Fatalf("expected borrowed chunks to share the buffer")
+	}
+	if &clone.Data[0] == &first.Data[0] || !bytes.Equal(clone.Data, first.Data) {
+		t.Fatalf("expected clone to have a copy of the data")
+	}
+}
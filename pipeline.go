@@ -0,0 +1,127 @@
+package fastcdc
+
+import (
+	"hash"
+	"io"
+	"runtime"
+	"sync"
+)
+
+// hashJob is a chunk copied out of the Chunker buffer for hashing
+type hashJob struct {
+	index int
+	chunk Chunk
+	buf   []byte        // Data and Sum, returned to the free list after fn
+	done  chan struct{} // Closed once Sum is set
+}
+
+// HashChunks reads the chunks of c and calls fn with each, in stream
+// order, with Sum set to its hash from newHash. Chunks are copied and
+// hashed by a pool of workers while c scans on, so throughput does not
+// drop to the combined cost of scanning and hashing. At most twice workers
+// chunks are in flight. If workers is zero or less, runtime.NumCPU()
+// workers are used.
+//
+// Chunk data is only valid during the call to fn. An error from c or fn
+// stops reading and is returned.
+func HashChunks(c *Chunker, newHash func() hash.Hash, workers int, fn func(Chunk) error) error {
+	return hashChunks(c, newHash, workers, true, func(_ int, chunk Chunk) error {
+		return fn(chunk)
+	})
+}
+
+// HashChunksUnordered is HashChunks calling fn with each chunk as soon as
+// it is hashed, along with its index in the stream. A slow chunk then does
+// not hold back the ones after it. Calls to fn are not concurrent.
+func HashChunksUnordered(c *Chunker, newHash func() hash.Hash, workers int, fn func(i int, chunk Chunk) error) error {
+	return hashChunks(c, newHash, workers, false, fn)
+}
+
+func hashChunks(c *Chunker, newHash func() hash.Hash, workers int, ordered bool, fn func(int, Chunk) error) error {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	size := newHash().Size()
+
+	free := make(chan []byte, 2*workers)
+	for range cap(free) {
+		free <- make([]byte, c.maxSize+size)
+	}
+	jobs := make(chan *hashJob)
+	out := make(chan *hashJob, cap(free)) // Jobs in stream or completion order
+	stop := make(chan struct{})
+	errc := make(chan error, 1)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h := newHash()
+			for job := range jobs {
+				h.Reset()
+				h.Write(job.chunk.Data)
+				job.chunk.Sum = h.Sum(job.buf[job.chunk.Length:job.chunk.Length])
+				close(job.done)
+				if !ordered {
+					out <- job
+				}
+			}
+		}()
+	}
+
+	// Scan in the background, handing chunks to workers and the consumer
+	go func() {
+		defer func() {
+			close(jobs)
+			wg.Wait()
+			close(out)
+		}()
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			chunk, err := c.Next()
+			if err != nil {
+				if err != io.EOF {
+					errc <- err
+				}
+				return
+			}
+
+			var buf []byte
+			select {
+			case buf = <-free:
+			case <-stop:
+				return
+			}
+			job := &hashJob{index: i, chunk: chunk, buf: buf, done: make(chan struct{})}
+			job.chunk.Data = buf[:copy(buf, chunk.Data)]
+			jobs <- job
+			if ordered {
+				out <- job
+			}
+		}
+	}()
+
+	var err error
+	for job := range out {
+		<-job.done
+		if err == nil {
+			if err = fn(job.index, job.chunk); err != nil {
+				close(stop)
+			}
+		}
+		free <- job.buf
+	}
+	if err != nil {
+		return err
+	}
+	select {
+	case err = <-errc:
+	default:
+	}
+	return err
+}
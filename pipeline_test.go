@@ -0,0 +1,90 @@
+package fastcdc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"testing/iotest"
+)
+
+func TestHashChunks(t *testing.T) {
+	data := make([]byte, 2*miB)
+	fillLCG(data, 14)
+	expected := Rechunk(data, DefaultParams)
+
+	for _, workers := range []int{1, 4} {
+		i := 0
+		err := HashChunks(NewChunker(bytes.NewReader(data)), sha256.New, workers, func(c Chunk) error {
+			e := expected[i]
+			sum := sha256.Sum256(e.Data)
+			if c.Offset != e.Offset || !bytes.Equal(c.Data, e.Data) || !bytes.Equal(c.Sum, sum[:]) {
+				t.Fatalf("workers %d: chunk %d mismatch at offset %d", workers, i, c.Offset)
+			}
+			i++
+			return nil
+		})
+		if err != nil || i != len(expected) {
+			t.Fatalf("workers %d: expected %d chunks, got %d (%v)", workers, len(expected), i, err)
+		}
+	}
+
+	// Errors from fn and from reading stop the pipeline
+	errStop := errors.New("stop")
+	n := 0
+	err := HashChunks(NewChunker(bytes.NewReader(data)), sha256.New, 2, func(c Chunk) error {
+		if n++; n == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || n != 3 {
+		t.Fatalf("expected stop after 3 chunks, got %d (%v)", n, err)
+	}
+
+	r := iotest.TimeoutReader(bytes.NewReader(data))
+	err = HashChunks(NewChunker(r), sha256.New, 2, func(c Chunk) error { return nil })
+	if err != iotest.ErrTimeout {
+		t.Fatalf("expected read error, got %v", err)
+	}
+}
+
+func TestHashChunksUnordered(t *testing.T) {
+	data := make([]byte, 2*miB)
+	fillLCG(data, 17)
+	expected := Rechunk(data, DefaultParams)
+
+	seen := make([]bool, len(expected))
+	err := HashChunksUnordered(NewChunker(bytes.NewReader(data)), sha256.New, 4, func(i int, c Chunk) error {
+		e := expected[i]
+		sum := sha256.Sum256(e.Data)
+		if seen[i] || c.Offset != e.Offset || !bytes.Equal(c.Data, e.Data) || !bytes.Equal(c.Sum, sum[:]) {
+			t.Fatalf("chunk %d mismatch at offset %d", i, c.Offset)
+		}
+		seen[i] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, ok := range seen {
+		if !ok {
+			t.Fatalf("chunk %d missing", i)
+		}
+	}
+}
+
+func BenchmarkHashChunks(b *testing.B) {
+	data := make([]byte, 8*miB)
+	fillLCG(data, 1)
+	r := bytes.NewReader(data)
+	c := NewChunker(r)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for range b.N {
+		r.Reset(data)
+		c.Reset(r)
+		HashChunks(c, sha256.New, 0, func(Chunk) error { return nil })
+	}
+}
@@ -0,0 +1,26 @@
+package fastcdc
+
+// BoundaryPolicy lets format-aware plugins influence where chunks are cut
+// without changing the core chunking loop. Policies are consulted for each
+// chunk in the order they were added.
+type BoundaryPolicy interface {
+	// Hint is called before scanning, with offset being the stream offset
+	// of the chunk start and data the bytes available from there. It
+	// returns the position of a forced boundary in data, or 0 for none.
+	// Cut points are only searched before the boundary, and hints beyond
+	// the maximum chunk size are ignored.
+	Hint(offset int, data []byte) int
+
+	// Adjust is called with a content-defined cut point in data and
+	// returns it, possibly moved. Data is limited to the maximum chunk
+	// size and to the first forced boundary. Cut points at a forced
+	// boundary or at the end of the stream are not adjusted.
+	Adjust(offset int, data []byte, cutPoint int) int
+}
+
+// WithBoundaryPolicy adds a policy to the Chunker
+func WithBoundaryPolicy(p BoundaryPolicy) Option {
+	return func(c *Chunker) {
+		c.policies = append(c.policies, p)
+	}
+}
@@ -0,0 +1,59 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// everyPolicy forces boundaries at multiples of n and shortens all other
+// cut points by a byte
+type everyPolicy int
+
+func (n everyPolicy) Hint(offset int, data []byte) int {
+	return int(n) - offset%int(n)
+}
+
+func (n everyPolicy) Adjust(offset int, data []byte, cutPoint int) int {
+	return cutPoint - 1
+}
+
+func TestBoundaryPolicy(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 42)
+
+	const every = 20000
+	chunker := NewChunker(bytes.NewReader(data), WithBoundaryPolicy(everyPolicy(every)))
+
+	plain := map[int]bool{}
+	for _, chunk := range Rechunk(data, DefaultParams) {
+		plain[chunk.Offset+len(chunk.Data)] = true
+	}
+
+	cuts := map[int]bool{}
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		if !bytes.Equal(chunk.Data, data[chunk.Offset:chunk.Offset+len(chunk.Data)]) {
+			t.Fatalf("chunk data mismatch at offset %d", chunk.Offset)
+		}
+
+		// Forced and final cuts are never adjusted
+		end := chunk.Offset + len(chunk.Data)
+		if end%every != 0 && end != len(data) && plain[end] {
+			t.Errorf("expected cut at %d to be adjusted", end)
+		}
+		cuts[end] = true
+	}
+
+	for end := every; end < len(data); end += every {
+		if !cuts[end] {
+			t.Errorf("expected forced cut at %d", end)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package fastcdc
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrUnknownPreset is wrapped by errors for preset names not in presets
+var ErrUnknownPreset = errors.New("fastcdc: unknown preset")
+
+// presets are chunk sizes for common kinds of data
+var presets = map[string]Params{
+	"default-8k":  DefaultParams,
+	"small-files": {MinSize: 512, AvgSize: 2 * kiB, MaxSize: 8 * kiB},
+	"documents":   {MinSize: 1 * kiB, AvgSize: 4 * kiB, MaxSize: 16 * kiB},
+	"vm-images":   {MinSize: 16 * kiB, AvgSize: 64 * kiB, MaxSize: 256 * kiB},
+	"large-files": {MinSize: 256 * kiB, AvgSize: 1 * miB, MaxSize: 4 * miB},
+}
+
+// Preset returns the params of a named preset, so that deployments can
+// standardize chunk sizes by name in configuration
+func Preset(name string) (Params, error) {
+	p, ok := presets[name]
+	if !ok {
+		return Params{}, fmt.Errorf("%w %q", ErrUnknownPreset, name)
+	}
+	return p, nil
+}
+
+// PresetNames returns the names of the presets in sorted order
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,28 @@
+package fastcdc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	names := PresetNames()
+	if len(names) == 0 {
+		t.Fatal("expected presets")
+	}
+	for _, name := range names {
+		p, err := Preset(name)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if p.MinSize <= 0 || p.MinSize >= p.AvgSize || p.AvgSize >= p.MaxSize || p.AvgSize&(p.AvgSize-1) != 0 {
+			t.Errorf("%s: invalid params %v", name, p)
+		}
+	}
+	if p, _ := Preset("default-8k"); p != DefaultParams {
+		t.Errorf("expected default-8k to be DefaultParams, got %v", p)
+	}
+	if _, err := Preset("nonexistent"); !errors.Is(err, ErrUnknownPreset) {
+		t.Errorf("expected ErrUnknownPreset, got %v", err)
+	}
+}
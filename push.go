@@ -0,0 +1,110 @@
+package fastcdc
+
+// PushChunker chunks data that is pushed to it piece by piece, for callers
+// that receive data in callbacks instead of reading from an io.Reader. It
+// produces the same chunks as a Chunker with the same options, but does
+// not support boundary policies or limits.
+type PushChunker struct {
+	c        Chunker
+	buf      []byte // Pending data, starting from the previous chunk returned
+	start    int    // Start of the pending chunk in buf
+	offset   int    // Stream offset of buf[0]
+	scanning bool   // Whether the pending chunk has been partially scanned
+	i        int    // Scan position in the pending chunk
+	fp       uint64 // Fingerprint at the scan position
+	sums     []byte // Hashes of the chunks returned by the last call
+}
+
+// NewPushChunker returns a PushChunker using the default params and opts
+func NewPushChunker(opts ...Option) *PushChunker {
+	pc := &PushChunker{}
+	pc.c.setParams(DefaultParams)
+	for _, opt := range opts {
+		opt(&pc.c)
+	}
+	return pc
+}
+
+// Push adds data and returns the chunks that it completes. Unless the Copy
+// ownership is used, the data and sums of the chunks are valid until the
+// next call to Push or Close.
+func (pc *PushChunker) Push(data []byte) []Chunk {
+	pc.compact()
+	pc.sums = pc.sums[:0]
+	pc.buf = append(pc.buf, data...)
+
+	var chunks []Chunk
+	for {
+		pending := pc.buf[pc.start:]
+		if len(pending) <= pc.c.minSize {
+			return chunks
+		}
+		if !pc.scanning {
+			pc.i, pc.fp, pc.scanning = pc.c.minSize, 0, true
+		}
+
+		// A cut before the end of the data is final, as is one at maxSize
+		cutPoint, reason, fp := pc.c.scan(pending, pc.i, pc.fp)
+		if reason == CutEOF {
+			pc.i, pc.fp = cutPoint, fp
+			return chunks
+		}
+		chunks = append(chunks, pc.chunk(cutPoint, reason, false))
+	}
+}
+
+// Close returns the last chunk, with Final set, if any data is pending.
+// The PushChunker can then be reused for a new stream.
+func (pc *PushChunker) Close() []Chunk {
+	pc.compact()
+	pc.sums = pc.sums[:0]
+
+	var chunks []Chunk
+	if len(pc.buf) > 0 {
+		chunks = append(chunks, pc.chunk(len(pc.buf), CutEOF, true))
+	}
+	pc.buf = pc.buf[:0]
+	pc.start, pc.offset = 0, 0
+	pc.c.putHasher()
+	return chunks
+}
+
+// chunk returns the pending chunk ending at cutPoint and starts the next
+func (pc *PushChunker) chunk(cutPoint int, reason CutReason, final bool) Chunk {
+	chunk := Chunk{
+		Offset: pc.offset + pc.start,
+		Length: cutPoint,
+		Data:   pc.buf[pc.start : pc.start+cutPoint],
+		Reason: reason,
+		Final:  final,
+	}
+	if pc.c.entropyThreshold > 0 {
+		chunk.HighEntropy = Entropy(chunk.Data) > pc.c.entropyThreshold
+	}
+	if pc.c.zeroDetection {
+		chunk.Zero = isZero(chunk.Data)
+	}
+	if pc.c.hashPool != nil {
+		// Push returns several chunks, so each needs its own sum
+		n := len(pc.sums)
+		pc.sums = append(pc.sums, pc.c.hashSum(chunk.Data)...)
+		chunk.Sum = pc.sums[n:len(pc.sums):len(pc.sums)]
+	}
+	if pc.c.ownership == Copy {
+		chunk = chunk.Clone()
+	}
+
+	pc.start += cutPoint
+	pc.scanning = false
+	return chunk
+}
+
+// compact drops the data of chunks already returned
+func (pc *PushChunker) compact() {
+	if pc.start > 0 {
+		n := copy(pc.buf, pc.buf[pc.start:])
+		pc.buf = pc.buf[:n]
+		pc.offset += pc.start
+		pc.start = 0
+	}
+}
@@ -0,0 +1,83 @@
+package fastcdc
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestPushChunker(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 1)
+	p := Params{MinSize: 1 * kiB, AvgSize: 4 * kiB, MaxSize: 8 * kiB}
+	expected := Rechunk(data, p)
+
+	// Pieces of various sizes, from single bytes to more than maxSize
+	for _, piece := range []int{1, 100, 4 * kiB, 20 * kiB, len(data)} {
+		pc := NewPushChunker(WithParams(p))
+		var got []Chunk
+		for pos := 0; pos < len(data); pos += piece {
+			for _, c := range pc.Push(data[pos:min(pos+piece, len(data))]) {
+				if !bytes.Equal(c.Data, data[c.Offset:c.Offset+c.Length]) {
+					t.Fatalf("piece %d: chunk data mismatch at offset %d", piece, c.Offset)
+				}
+				c.Data = nil
+				got = append(got, c)
+			}
+		}
+		got = append(got, pc.Close()...)
+
+		if len(got) != len(expected) {
+			t.Fatalf("piece %d: expected %d chunks, got %d", piece, len(expected), len(got))
+		}
+		for i, c := range got {
+			e := expected[i]
+			if c.Offset != e.Offset || c.Length != e.Length || c.Reason != e.Reason || c.Final != e.Final {
+				t.Fatalf("piece %d: chunk %d: expected %d+%d (%v, final %v), got %d+%d (%v, final %v)",
+					piece, i, e.Offset, e.Length, e.Reason, e.Final, c.Offset, c.Length, c.Reason, c.Final)
+			}
+		}
+	}
+}
+
+func TestPushChunkerReuse(t *testing.T) {
+	pc := NewPushChunker()
+	if chunks := pc.Close(); len(chunks) != 0 {
+		t.Errorf("expected no chunks for empty stream, got %d", len(chunks))
+	}
+
+	pc.Push([]byte("hello"))
+	chunks := pc.Close()
+	if len(chunks) != 1 || string(chunks[0].Data) != "hello" || !chunks[0].Final {
+		t.Fatalf("expected final chunk \"hello\", got %+v", chunks)
+	}
+
+	pc.Push([]byte("again"))
+	chunks = pc.Close()
+	if len(chunks) != 1 || chunks[0].Offset != 0 || string(chunks[0].Data) != "again" {
+		t.Errorf("expected new stream from offset 0, got %+v", chunks)
+	}
+}
+
+func TestPushChunkerHash(t *testing.T) {
+	data := make([]byte, 256*kiB)
+	fillLCG(data, 2)
+	pc := NewPushChunker(WithHash(sha256.New))
+
+	chunks := pc.Push(data)
+	if len(chunks) < 2 {
+		t.Fatalf("expected several chunks, got %d", len(chunks))
+	}
+	// Sums are valid until the next call, like data
+	checkSums(t, chunks)
+	checkSums(t, pc.Close())
+}
+
+func checkSums(t *testing.T, chunks []Chunk) {
+	t.Helper()
+	for _, c := range chunks {
+		if sum := sha256.Sum256(c.Data); !bytes.Equal(c.Sum, sum[:]) {
+			t.Errorf("chunk at %d: expected sum %x, got %x", c.Offset, sum, c.Sum)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package fastcdc
+
+import (
+	"io"
+	"runtime"
+	"sync"
+)
+
+// WithReadAhead makes the Chunker read from its reader in a background
+// goroutine, keeping up to n blocks of max size read ahead, so that
+// reading and scanning overlap. This helps most with high-latency readers
+// such as network streams. The goroutine stops at the end of the stream,
+// on Reset, or once the Chunker is garbage collected. Reset waits for a
+// read in progress to return, so the old reader can then be closed.
+func WithReadAhead(n int) Option {
+	return func(c *Chunker) {
+		c.readAhead = n
+		c.readAheadLimit = 0
+	}
+}
+
+// WithAdaptiveReadAhead is like WithReadAhead, but starts with one block
+// and adapts the number of blocks to the reader. Each time the Chunker
+// has to wait for data another block is added, up to limit bytes, and
+// blocks are dropped again while all others are ready, as with fast local
+// readers.
+func WithAdaptiveReadAhead(limit int) Option {
+	return func(c *Chunker) {
+		c.readAhead = 1
+		c.readAheadLimit = limit
+	}
+}
+
+// readAhead wraps readAheadState so a finalizer can stop the goroutine,
+// which only references the state
+type readAhead struct {
+	*readAheadState
+}
+
+type readAheadState struct {
+	full   chan readBlock // Blocks read, in order
+	free   chan []byte    // Buffers available for reading
+	stop   chan struct{}
+	done   chan struct{} // Closed once run returns
+	retry  chan struct{} // Resumes reading after an error
+	once   sync.Once
+	cur    readBlock // Block being consumed
+	failed bool      // Whether Read returned an error, so the next one retries
+
+	size     int // Size of each buffer
+	buffers  int // Number of buffers in use
+	limit    int // Maximum number of buffers
+	adaptive bool
+}
+
+type readBlock struct {
+	buf  []byte // Whole buffer, to be returned to free
+	data []byte // Unconsumed data
+	err  error  // Error after the data
+}
+
+// newReadAhead starts reading r into n buffers of size bytes, adapting
+// the number of buffers up to limit if it is larger than n
+func newReadAhead(r io.Reader, n, limit, size int) *readAhead {
+	s := &readAheadState{
+		full:     make(chan readBlock, max(n, limit)),
+		free:     make(chan []byte, max(n, limit)),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		retry:    make(chan struct{}, 1),
+		size:     size,
+		buffers:  n,
+		limit:    max(n, limit),
+		adaptive: limit > n,
+	}
+	for range n {
+		s.free <- make([]byte, size)
+	}
+	go s.run(r)
+
+	ra := &readAhead{s}
+	runtime.SetFinalizer(ra, (*readAhead).cancel)
+	return ra
+}
+
+func (s *readAheadState) run(r io.Reader) {
+	defer close(s.done)
+	for {
+		var buf []byte
+		select {
+		case buf = <-s.free:
+		case <-s.stop:
+			return
+		}
+
+		n, err := io.ReadFull(r, buf)
+		if err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		select {
+		case s.full <- readBlock{buf: buf, data: buf[:n], err: err}:
+		case <-s.stop:
+			return
+		}
+		if err == io.EOF {
+			return
+		}
+
+		// Read again only once the error has been returned and Read called
+		// again, as a reader without read-ahead would
+		if err != nil {
+			select {
+			case <-s.retry:
+			case <-s.stop:
+				return
+			}
+		}
+	}
+}
+
+func (s *readAheadState) Read(p []byte) (int, error) {
+	if s.failed {
+		s.failed = false
+		s.retry <- struct{}{}
+	}
+	for len(s.cur.data) == 0 {
+		if err := s.cur.err; err == io.EOF {
+			return 0, err
+		} else if err != nil {
+			s.release(s.cur.buf)
+			s.cur = readBlock{}
+			s.failed = true
+			return 0, err
+		}
+		if s.cur.buf != nil {
+			s.release(s.cur.buf)
+		}
+		s.cur = s.next()
+	}
+
+	n := copy(p, s.cur.data)
+	s.cur.data = s.cur.data[n:]
+	return n, nil
+}
+
+// next returns the next block, adding a buffer if it has to wait
+func (s *readAheadState) next() readBlock {
+	if s.adaptive {
+		select {
+		case b := <-s.full:
+			return b
+		default:
+		}
+		if s.buffers < s.limit {
+			s.buffers++
+			s.free <- make([]byte, s.size)
+		}
+	}
+	return <-s.full
+}
+
+// release returns a consumed buffer for reading, or drops it if all other
+// buffers are already waiting to be consumed
+func (s *readAheadState) release(buf []byte) {
+	if s.adaptive && s.buffers > 1 && len(s.full) == s.buffers-1 {
+		s.buffers--
+		return
+	}
+	s.free <- buf
+}
+
+// cancel stops the goroutine once any read in progress returns
+func (ra *readAhead) cancel() {
+	ra.once.Do(func() {
+		close(ra.stop)
+	})
+}
+
+// close stops the goroutine and waits for it to return, so the reader
+// can be closed or reused
+func (ra *readAhead) close() {
+	ra.cancel()
+	<-ra.done
+}
@@ -0,0 +1,206 @@
+package fastcdc
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestReadAhead(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 3)
+	expected := Rechunk(data, DefaultParams)
+
+	readers := map[string]func() io.Reader{
+		"plain":    func() io.Reader { return bytes.NewReader(data) },
+		"one byte": func() io.Reader { return iotest.OneByteReader(bytes.NewReader(data)) },
+		"data err": func() io.Reader { return iotest.DataErrReader(bytes.NewReader(data)) },
+	}
+	for name, newReader := range readers {
+		for _, n := range []int{1, 4} {
+			chunker := NewChunker(newReader(), WithReadAhead(n))
+			for i := 0; ; i++ {
+				chunk, err := chunker.Next()
+				if err == io.EOF {
+					if i != len(expected) {
+						t.Fatalf("%s, %d blocks: expected %d chunks, got %d", name, n, len(expected), i)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s, %d blocks: unexpected error: %v", name, n, err)
+				}
+				if chunk.Offset != expected[i].Offset || !bytes.Equal(chunk.Data, expected[i].Data) {
+					t.Fatalf("%s, %d blocks: chunk %d mismatch at offset %d", name, n, i, chunk.Offset)
+				}
+			}
+		}
+	}
+}
+
+func TestReadAheadError(t *testing.T) {
+	data := make([]byte, 200*kiB)
+	fillLCG(data, 4)
+	readErr := errors.New("read failed")
+
+	chunker := NewChunker(io.MultiReader(bytes.NewReader(data), iotest.ErrReader(readErr)), WithReadAhead(2))
+	total := 0
+	for {
+		chunk, err := chunker.Next()
+		if err != nil {
+			if err != readErr {
+				t.Fatalf("expected read error, got %v", err)
+			}
+			break
+		}
+		total += chunk.Length
+	}
+	if total > len(data) {
+		t.Errorf("expected at most %d bytes in chunks, got %d", len(data), total)
+	}
+}
+
+// flakyReader fails once with err when after bytes have been read
+type flakyReader struct {
+	r     io.Reader
+	after int
+	err   error
+}
+
+func (r *flakyReader) Read(p []byte) (int, error) {
+	if r.after == 0 && r.err != nil {
+		err := r.err
+		r.err = nil
+		return 0, err
+	}
+	if r.err != nil {
+		p = p[:min(len(p), r.after)]
+	}
+	n, err := r.r.Read(p)
+	r.after -= n
+	return n, err
+}
+
+func TestReadAheadRetry(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 6)
+	expected := Rechunk(data, DefaultParams)
+	readErr := errors.New("timeout")
+
+	// A later call retries the read after an error, as without read-ahead
+	for _, opt := range []Option{WithReadAhead(2), WithAdaptiveReadAhead(1 * miB)} {
+		chunker := NewChunker(&flakyReader{r: bytes.NewReader(data), after: 300 * kiB, err: readErr}, opt)
+		var got []Chunk
+		failed := false
+		for {
+			chunk, err := chunker.Next()
+			if err == io.EOF {
+				break
+			}
+			if err == readErr && !failed {
+				failed = true
+				continue
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got = append(got, chunk.Clone())
+		}
+		if !failed || len(got) != len(expected) {
+			t.Fatalf("expected one error and %d chunks, got %v and %d", len(expected), failed, len(got))
+		}
+		for i, c := range got {
+			if c.Offset != expected[i].Offset || !bytes.Equal(c.Data, expected[i].Data) {
+				t.Fatalf("chunk %d mismatch at offset %d", i, c.Offset)
+			}
+		}
+	}
+}
+
+// endless is a reader of unlimited pseudorandom data
+type endless struct{ seed uint32 }
+
+func (r *endless) Read(p []byte) (int, error) {
+	r.seed++
+	fillLCG(p, r.seed)
+	return len(p), nil
+}
+
+func TestReadAheadStop(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	chunker := NewChunker(&endless{}, WithReadAhead(2))
+	if _, err := chunker.Next(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	chunker.Reset(bytes.NewReader([]byte("short")))
+	chunk, err := chunker.Next()
+	if err != nil || string(chunk.Data) != "short" {
+		t.Fatalf("expected chunk \"short\", got %q, %v", chunk.Data, err)
+	}
+	if _, err := chunker.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	// Both background readers have stopped
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > before; {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d goroutines, got %d", before, runtime.NumGoroutine())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// slowReader delays each read, like a high-latency network reader
+type slowReader struct {
+	r     io.Reader
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.delay)
+	return r.r.Read(p)
+}
+
+func TestAdaptiveReadAhead(t *testing.T) {
+	data := make([]byte, 2*miB)
+	fillLCG(data, 5)
+	p := Params{MinSize: 1 * kiB, AvgSize: 4 * kiB, MaxSize: 16 * kiB}
+	expected := Rechunk(data, p)
+
+	run := func(r io.Reader, consumerDelay time.Duration) *Chunker {
+		chunker := NewChunker(r, WithParams(p), WithAdaptiveReadAhead(8*p.MaxSize))
+		for i := 0; ; i++ {
+			chunk, err := chunker.Next()
+			if err == io.EOF {
+				if i != len(expected) {
+					t.Fatalf("expected %d chunks, got %d", len(expected), i)
+				}
+				return chunker
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if chunk.Offset != expected[i].Offset || !bytes.Equal(chunk.Data, expected[i].Data) {
+				t.Fatalf("chunk %d mismatch at offset %d", i, chunk.Offset)
+			}
+			time.Sleep(consumerDelay)
+		}
+	}
+
+	// A slow reader makes the Chunker wait, growing read-ahead to the limit
+	chunker := run(&slowReader{bytes.NewReader(data), time.Millisecond}, 0)
+	if n := chunker.ra.buffers; n != 8 {
+		t.Errorf("expected 8 buffers for slow reader, got %d", n)
+	}
+
+	// A fast reader keeps blocks ready, so read-ahead shrinks back
+	chunker = run(bytes.NewReader(data), 100*time.Microsecond)
+	if n := chunker.ra.buffers; n > 2 {
+		t.Errorf("expected at most 2 buffers for fast reader, got %d", n)
+	}
+}
@@ -0,0 +1,32 @@
+package fastcdc
+
+import (
+	"fmt"
+)
+
+// CutReason tells why a chunk boundary was chosen
+type CutReason int
+
+const (
+	CutMaskS   CutReason = iota // Gear hash matched the small mask
+	CutMaskL                    // Gear hash matched the large mask
+	CutMaxSize                  // Chunk reached the maximum size
+	CutEOF                      // Stream ended, so the chunk may be truncated
+	CutPolicy                   // A BoundaryPolicy forced or moved the cut
+)
+
+func (r CutReason) String() string {
+	switch r {
+	case CutMaskS:
+		return "maskS"
+	case CutMaskL:
+		return "maskL"
+	case CutMaxSize:
+		return "max-size"
+	case CutEOF:
+		return "eof"
+	case CutPolicy:
+		return "policy"
+	}
+	return fmt.Sprintf("CutReason(%d)", int(r))
+}
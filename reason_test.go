@@ -0,0 +1,117 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestCutReason(t *testing.T) {
+	data := make([]byte, 2*miB)
+	fillLCG(data, 42)
+
+	// A tight maximum gives chunks of every kind
+	p := Params{MinSize: 2 * kiB, AvgSize: 8 * kiB, MaxSize: 12 * kiB}
+	chunker := NewChunkerWithParams(bytes.NewReader(data), p.MinSize, p.AvgSize, p.MaxSize)
+
+	counts := map[CutReason]int{}
+	var last Chunk
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		if last.Final {
+			t.Errorf("chunk at %d follows a final chunk", chunk.Offset)
+		}
+
+		n := len(chunk.Data)
+		switch chunk.Reason {
+		case CutMaskS:
+			if n < p.MinSize || n >= p.AvgSize {
+				t.Errorf("maskS chunk at %d has size %d", chunk.Offset, n)
+			}
+		case CutMaskL:
+			if n < p.AvgSize || n >= p.MaxSize {
+				t.Errorf("maskL chunk at %d has size %d", chunk.Offset, n)
+			}
+		case CutMaxSize:
+			if n != p.MaxSize {
+				t.Errorf("max-size chunk at %d has size %d", chunk.Offset, n)
+			}
+		case CutEOF:
+			if !chunk.Final {
+				t.Errorf("eof chunk at %d is not final", chunk.Offset)
+			}
+		}
+		counts[chunk.Reason]++
+		last = chunk
+	}
+
+	if !last.Final || last.Reason != CutEOF {
+		t.Errorf("expected final eof chunk, got %v final %v", last.Reason, last.Final)
+	}
+	for _, r := range []CutReason{CutMaskS, CutMaskL, CutMaxSize} {
+		if counts[r] == 0 {
+			t.Errorf("expected some %v chunks", r)
+		}
+	}
+
+	// Rechunk reports the same
+	chunks := Rechunk(data, p)
+	if c := chunks[len(chunks)-1]; !c.Final || c.Reason != CutEOF {
+		t.Errorf("expected final eof chunk from Rechunk, got %v final %v", c.Reason, c.Final)
+	}
+}
+
+func TestFinalAtMaxSize(t *testing.T) {
+	// Zeros never match a mask, so every chunk is cut at maximum size
+	data := make([]byte, 3*DefaultParams.MaxSize)
+
+	chunker := NewChunker(bytes.NewReader(data))
+	for i := 0; i < 3; i++ {
+		chunk, err := chunker.Next()
+		if err != nil {
+			t.Fatalf("error getting chunk %d: %v", i, err)
+		}
+		if chunk.Reason != CutMaxSize || chunk.Final != (i == 2) {
+			t.Errorf("chunk %d: got %v final %v", i, chunk.Reason, chunk.Final)
+		}
+	}
+
+	chunker = NewChunker(bytes.NewReader(data), WithMaxChunks(2))
+	chunker.Next()
+	if chunk, _ := chunker.Next(); !chunk.Final {
+		t.Errorf("expected last chunk before limit to be final")
+	}
+}
+
+func TestFixedChunkerFinal(t *testing.T) {
+	for _, size := range []int{16 * kiB, 16*kiB + 1} {
+		chunker := NewFixedChunker(bytes.NewReader(make([]byte, size)), 8*kiB)
+
+		var chunks []Chunk
+		for {
+			chunk, err := chunker.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("error getting next chunk: %v", err)
+			}
+			chunks = append(chunks, chunk)
+		}
+
+		for i, chunk := range chunks {
+			if chunk.Final != (i == len(chunks)-1) {
+				t.Errorf("size %d: chunk %d final %v", size, i, chunk.Final)
+			}
+		}
+		if last := chunks[len(chunks)-1]; size%(8*kiB) != 0 && last.Reason != CutEOF {
+			t.Errorf("size %d: expected short final chunk to be eof, got %v", size, last.Reason)
+		}
+	}
+}
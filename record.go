@@ -0,0 +1,36 @@
+package fastcdc
+
+// WithRecordDelimiter moves each cut point to just after the nearest delim
+// byte within window bytes, so that chunks of newline-delimited data like
+// logs and NDJSON never split a record. Cut points without a delimiter in
+// the window are left as is. Chunks never exceed the maximum size, but may
+// end up shorter than the minimum size.
+func WithRecordDelimiter(delim byte, window int) Option {
+	return WithBoundaryPolicy(recordPolicy{delim: delim, window: window})
+}
+
+type recordPolicy struct {
+	delim  byte
+	window int
+}
+
+func (p recordPolicy) Hint(offset int, data []byte) int {
+	return 0
+}
+
+// Adjust returns the cut point nearest to cutPoint that ends data with the
+// record delimiter, searching both directions within the window
+func (p recordPolicy) Adjust(offset int, data []byte, cutPoint int) int {
+	lo := max(cutPoint-p.window, 1)
+	hi := min(cutPoint+p.window, len(data))
+
+	for d := 0; cutPoint-d >= lo || cutPoint+d <= hi; d++ {
+		if back := cutPoint - d; back >= lo && data[back-1] == p.delim {
+			return back
+		}
+		if fwd := cutPoint + d; fwd <= hi && data[fwd-1] == p.delim {
+			return fwd
+		}
+	}
+	return cutPoint
+}
@@ -0,0 +1,73 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestRecordDelimiter(t *testing.T) {
+	// Lines of 1 to 200 pseudorandom letters
+	random := make([]byte, 2*miB)
+	fillLCG(random, 42)
+
+	var data []byte
+	for i := 0; len(data) < 1*miB; {
+		n := 1 + int(random[i])*200/256
+		for _, b := range random[i+1 : i+1+n] {
+			data = append(data, 'a'+b%26)
+		}
+		data = append(data, '\n')
+		i += n + 1
+	}
+
+	chunker := NewChunker(bytes.NewReader(data), WithRecordDelimiter('\n', 256))
+
+	total := 0
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		if len(chunk.Data) > chunker.maxSize {
+			t.Errorf("chunk at %d exceeds max size: %d", chunk.Offset, len(chunk.Data))
+		}
+		if chunk.Data[len(chunk.Data)-1] != '\n' {
+			t.Errorf("chunk at %d splits a record", chunk.Offset)
+		}
+		total += len(chunk.Data)
+	}
+	if total != len(data) {
+		t.Errorf("expected %d bytes in chunks, got %d", len(data), total)
+	}
+}
+
+func TestRecordDelimiterMissing(t *testing.T) {
+	// Without delimiters the cut points are unchanged
+	data := make([]byte, 256*kiB)
+	fillLCG(data, 42)
+	for i := range data {
+		if data[i] == '\n' {
+			data[i] = 0
+		}
+	}
+
+	plain := NewChunker(bytes.NewReader(data))
+	records := NewChunker(bytes.NewReader(data), WithRecordDelimiter('\n', 256))
+	for {
+		a, errA := plain.Next()
+		b, errB := records.Next()
+		if errA != errB {
+			t.Fatalf("expected same errors, got %v and %v", errA, errB)
+		}
+		if errA == io.EOF {
+			break
+		}
+		if a.Offset != b.Offset || len(a.Data) != len(b.Data) {
+			t.Fatalf("expected chunk %d+%d, got %d+%d", a.Offset, len(a.Data), b.Offset, len(b.Data))
+		}
+	}
+}
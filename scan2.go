@@ -0,0 +1,80 @@
+package fastcdc
+
+// WithTwoByteScan makes the Chunker roll the gear hash two bytes per loop
+// iteration, testing the first byte of each pair against masks shifted
+// left by one. It is faster, but cut points differ from the default scan
+// where a mask has its top bit set, so chunks do not dedup against those
+// of a Chunker without this option.
+func WithTwoByteScan() Option {
+	return func(c *Chunker) {
+		c.twoByte = true
+	}
+}
+
+// scan2 is scan processing two bytes per iteration. A byte left over at
+// the end of data is not scanned, so that a resumed search pairs bytes the
+// same way, and the returned position is then before the end of data.
+func (c *Chunker) scan2(data []byte, i int, fp uint64) (int, CutReason, uint64) {
+	gear := c.gear
+	if gear == nil {
+		gear = &G
+	}
+
+	// Shifting the fingerprint by two and the first byte's gear value by
+	// one leaves the fingerprint after the first byte shifted left by one
+	maskS, maskSls := c.maskS, c.maskS<<1
+	end := min(c.avgSize, len(data))
+	if i < end {
+		d := data[i:end]
+		for ; len(d) >= 2; d = d[2:] {
+			fp = (fp << 2) + gear[d[0]]<<1
+			if fp&maskSls == 0 {
+				return i, CutMaskS, fp >> 1
+			}
+			fp += gear[d[1]]
+			if fp&maskS == 0 {
+				return i + 1, CutMaskS, fp
+			}
+			i += 2
+		}
+		if len(d) == 1 && end == c.avgSize {
+			fp = (fp << 1) + gear[d[0]]
+			if fp&maskS == 0 {
+				return i, CutMaskS, fp
+			}
+			i++
+		}
+	}
+	if i < c.avgSize {
+		return i, CutEOF, fp
+	}
+
+	maskL, maskLls := c.maskL, c.maskL<<1
+	end = min(c.maxSize, len(data))
+	if i < end {
+		d := data[i:end]
+		for ; len(d) >= 2; d = d[2:] {
+			fp = (fp << 2) + gear[d[0]]<<1
+			if fp&maskLls == 0 {
+				return i, CutMaskL, fp >> 1
+			}
+			fp += gear[d[1]]
+			if fp&maskL == 0 {
+				return i + 1, CutMaskL, fp
+			}
+			i += 2
+		}
+		if len(d) == 1 && end == c.maxSize {
+			fp = (fp << 1) + gear[d[0]]
+			if fp&maskL == 0 {
+				return i, CutMaskL, fp
+			}
+			i++
+		}
+	}
+
+	if i == c.maxSize {
+		return i, CutMaxSize, fp
+	}
+	return i, CutEOF, fp
+}
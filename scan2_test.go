@@ -0,0 +1,95 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// chunkAll returns the chunks of data, with data copied
+func chunkAll(t *testing.T, data []byte, opts ...Option) []Chunk {
+	t.Helper()
+	var chunks []Chunk
+	chunker := NewChunker(bytes.NewReader(data), opts...)
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		chunk.Data = bytes.Clone(chunk.Data)
+		chunks = append(chunks, chunk)
+	}
+}
+
+func TestTwoByteScan(t *testing.T) {
+	data := make([]byte, 4*miB)
+	fillLCG(data, 8)
+
+	// Without the top mask bit set, cut points are the same as one byte
+	// at a time, including an odd number of bytes before avgSize
+	for _, p := range []Params{DefaultParams, {MinSize: 2*kiB + 1, AvgSize: 8 * kiB, MaxSize: 32*kiB + 1}} {
+		maskS, maskL := Masks(p.AvgSize)
+		if (maskS|maskL)>>63 != 0 {
+			t.Fatalf("expected masks without top bit for avg %d", p.AvgSize)
+		}
+		expected := chunkAll(t, data, WithParams(p))
+		got := chunkAll(t, data, WithParams(p), WithTwoByteScan())
+		if len(got) != len(expected) {
+			t.Fatalf("expected %d chunks, got %d", len(expected), len(got))
+		}
+		for i := range got {
+			if got[i].Offset != expected[i].Offset || got[i].Reason != expected[i].Reason {
+				t.Fatalf("chunk %d: expected offset %d (%v), got %d (%v)",
+					i, expected[i].Offset, expected[i].Reason, got[i].Offset, got[i].Reason)
+			}
+		}
+	}
+
+	// Otherwise chunks may differ, but are still valid
+	p := Params{MinSize: 256, AvgSize: 1 * kiB, MaxSize: 4 * kiB}
+	chunks := chunkAll(t, data, WithParams(p), WithTwoByteScan())
+	for i, c := range chunks {
+		c.Data = nil
+		chunks[i] = c
+	}
+	if err := Validate(chunks, int64(len(data)), p); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// Resuming the scan after partial reads gives the same chunks
+	o := NewOffsetChunker(bytes.NewReader(data), int64(len(data)), p, WithTwoByteScan())
+	for i := 0; ; i++ {
+		chunk, err := o.Next()
+		if err == io.EOF {
+			if i != len(chunks) {
+				t.Fatalf("expected %d offsets-only chunks, got %d", len(chunks), i)
+			}
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if chunk.Offset != chunks[i].Offset || chunk.Length != chunks[i].Length {
+			t.Fatalf("chunk %d: expected %d+%d, got %d+%d", i, chunks[i].Offset, chunks[i].Length, chunk.Offset, chunk.Length)
+		}
+	}
+
+	// and likewise when pushing data in odd-sized pieces
+	pc := NewPushChunker(WithParams(p), WithTwoByteScan())
+	var pushed []Chunk
+	for pos := 0; pos < len(data); pos += 777 {
+		pushed = append(pushed, pc.Push(data[pos:min(pos+777, len(data))])...)
+	}
+	pushed = append(pushed, pc.Close()...)
+	if len(pushed) != len(chunks) {
+		t.Fatalf("expected %d pushed chunks, got %d", len(chunks), len(pushed))
+	}
+	for i := range pushed {
+		if pushed[i].Offset != chunks[i].Offset {
+			t.Fatalf("chunk %d: expected offset %d, got %d", i, chunks[i].Offset, pushed[i].Offset)
+		}
+	}
+}
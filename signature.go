@@ -0,0 +1,250 @@
+package fastcdc
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// StrongSize is the number of bytes of SHA-256 kept in a signature entry
+const StrongSize = 8
+
+var signatureMagic = [4]byte{'F', 'C', 'D', 'S'}
+
+const signatureVersion = 1
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// ErrSignatureFormat is wrapped by errors for malformed signatures
+var ErrSignatureFormat = errors.New("fastcdc: invalid signature format")
+
+// SignatureEntry describes one chunk of a signed file
+type SignatureEntry struct {
+	Offset int64
+	Length int
+	Weak   uint64           // Gear fingerprint of the end of the chunk
+	Strong [StrongSize]byte // Truncated SHA-256 of the chunk
+}
+
+// Signature lists the chunks of a file compactly enough to keep next to a
+// backup, for finding the regions that changed since without the old data
+type Signature struct {
+	Params  Params
+	Entries []SignatureEntry
+}
+
+// Region is a byte range of a file
+type Region struct {
+	Offset int64
+	Length int64
+}
+
+// NewSignature chunks r with the given options and returns its signature.
+// A custom gear table is not recorded, so it must be given again to
+// CompareSignature.
+func NewSignature(r io.Reader, opts ...Option) (*Signature, error) {
+	c := NewChunker(r, opts...)
+	s := &Signature{Params: c.Params()}
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		s.Entries = append(s.Entries, SignatureEntry{
+			Offset: int64(chunk.Offset),
+			Length: chunk.Length,
+			Weak:   c.weak(chunk.Data),
+			Strong: strong(chunk.Data),
+		})
+	}
+}
+
+// WriteSignature writes the signature of r to w
+func WriteSignature(w io.Writer, r io.Reader, opts ...Option) error {
+	s, err := NewSignature(r, opts...)
+	if err != nil {
+		return err
+	}
+	_, err = s.WriteTo(w)
+	return err
+}
+
+// CompareSignature chunks r with the params of s and returns the regions
+// of r whose chunks do not appear anywhere in s, merged where adjacent.
+func CompareSignature(s *Signature, r io.Reader, opts ...Option) ([]Region, error) {
+	m := NewMatcher(s, opts...)
+	c := NewChunker(r, append([]Option{WithParams(s.Params)}, opts...)...)
+	var changed []Region
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return changed, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := m.Match(chunk.Data); ok {
+			continue
+		}
+		if n := len(changed); n > 0 && changed[n-1].Offset+changed[n-1].Length == int64(chunk.Offset) {
+			changed[n-1].Length += int64(chunk.Length)
+		} else {
+			changed = append(changed, Region{Offset: int64(chunk.Offset), Length: int64(chunk.Length)})
+		}
+	}
+}
+
+// Matcher looks up chunks in a signature
+type Matcher struct {
+	s     *Signature
+	known map[uint64][]int // Entries by weak fingerprint
+	c     Chunker          // Gear table
+}
+
+// NewMatcher indexes the entries of s. Of the options, only WithGearTable
+// applies.
+func NewMatcher(s *Signature, opts ...Option) *Matcher {
+	m := &Matcher{s: s, known: make(map[uint64][]int, len(s.Entries))}
+	for _, opt := range opts {
+		opt(&m.c)
+	}
+	for i, e := range s.Entries {
+		m.known[e.Weak] = append(m.known[e.Weak], i)
+	}
+	return m
+}
+
+// Match returns an entry of the signature with the same data as the chunk
+// data. Data is only hashed in full when its weak fingerprint matches.
+func (m *Matcher) Match(data []byte) (SignatureEntry, bool) {
+	candidates := m.known[m.c.weak(data)]
+	if len(candidates) == 0 {
+		return SignatureEntry{}, false
+	}
+	sum := strong(data)
+	for _, i := range candidates {
+		if e := m.s.Entries[i]; e.Length == len(data) && e.Strong == sum {
+			return e, true
+		}
+	}
+	return SignatureEntry{}, false
+}
+
+// weak returns the gear fingerprint of data, which only depends on its
+// last 64 bytes
+func (c *Chunker) weak(data []byte) uint64 {
+	gear := c.gear
+	if gear == nil {
+		gear = &G
+	}
+	var fp uint64
+	for _, b := range data[max(len(data)-64, 0):] {
+		fp = (fp << 1) + gear[b]
+	}
+	return fp
+}
+
+func strong(data []byte) [StrongSize]byte {
+	sum := sha256.Sum256(data)
+	return [StrongSize]byte(sum[:StrongSize])
+}
+
+// WriteTo writes the binary encoding of s to w: a magic, version and the
+// params as varints, then a varint length, the weak fingerprint and the
+// strong hash of each entry. A zero length ends the entries and is
+// followed by the varint entry count. With 8 KiB chunks an entry takes 18
+// bytes.
+func (s *Signature) WriteTo(w io.Writer) (int64, error) {
+	buf := append([]byte(nil), signatureMagic[:]...)
+	buf = binary.AppendUvarint(buf, signatureVersion)
+	buf = binary.AppendUvarint(buf, uint64(s.Params.MinSize))
+	buf = binary.AppendUvarint(buf, uint64(s.Params.AvgSize))
+	buf = binary.AppendUvarint(buf, uint64(s.Params.MaxSize))
+	for _, e := range s.Entries {
+		buf = binary.AppendUvarint(buf, uint64(e.Length))
+		buf = binary.LittleEndian.AppendUint64(buf, e.Weak)
+		buf = append(buf, e.Strong[:]...)
+	}
+	buf = binary.AppendUvarint(buf, 0)
+	buf = binary.AppendUvarint(buf, uint64(len(s.Entries)))
+	n, err := w.Write(buf)
+	return int64(n), err
+}
+
+// ReadSignature decodes a signature written by WriteTo. Unless r is an
+// io.ByteReader, it reads through a buffer and may consume bytes past the
+// end of the signature.
+func ReadSignature(r io.Reader) (*Signature, error) {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	var m [4]byte
+	if _, err := io.ReadFull(br, m[:]); err != nil || m != signatureMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrSignatureFormat)
+	}
+
+	var fields [4]uint64
+	for i := range fields {
+		v, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSignatureFormat, err)
+		}
+		fields[i] = v
+	}
+	if fields[0] != signatureVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrSignatureFormat, fields[0])
+	}
+	for _, v := range fields[1:] {
+		if v > MaxChunkSize {
+			return nil, fmt.Errorf("%w: size %d too large", ErrSignatureFormat, v)
+		}
+	}
+	s := &Signature{Params: Params{MinSize: int(fields[1]), AvgSize: int(fields[2]), MaxSize: int(fields[3])}}
+	if err := s.Params.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignatureFormat, err)
+	}
+
+	var offset int64
+	var entry [8 + StrongSize]byte
+	for {
+		length, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSignatureFormat, err)
+		}
+		if length == 0 {
+			break
+		}
+		if length > uint64(s.Params.MaxSize) {
+			return nil, fmt.Errorf("%w: chunk of %d bytes", ErrSignatureFormat, length)
+		}
+		if _, err := io.ReadFull(br, entry[:]); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrSignatureFormat, err)
+		}
+		s.Entries = append(s.Entries, SignatureEntry{
+			Offset: offset,
+			Length: int(length),
+			Weak:   binary.LittleEndian.Uint64(entry[:8]),
+			Strong: [StrongSize]byte(entry[8:]),
+		})
+		offset += int64(length)
+	}
+
+	count, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSignatureFormat, err)
+	}
+	if count != uint64(len(s.Entries)) {
+		return nil, fmt.Errorf("%w: expected %d entries, got %d", ErrSignatureFormat, count, len(s.Entries))
+	}
+	return s, nil
+}
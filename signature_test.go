@@ -0,0 +1,76 @@
+package fastcdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+func TestSignature(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 10)
+
+	var buf bytes.Buffer
+	if err := WriteSignature(&buf, bytes.NewReader(data)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s, err := ReadSignature(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Params != DefaultParams {
+		t.Fatalf("expected params %v, got %v", DefaultParams, s.Params)
+	}
+	expected, _ := NewSignature(bytes.NewReader(data))
+	if len(s.Entries) != len(expected.Entries) {
+		t.Fatalf("expected %d entries, got %d", len(expected.Entries), len(s.Entries))
+	}
+	for i, e := range s.Entries {
+		if e != expected.Entries[i] {
+			t.Fatalf("entry %d: expected %+v, got %+v", i, expected.Entries[i], e)
+		}
+	}
+
+	// Unchanged data has no changed regions
+	regions, err := CompareSignature(s, bytes.NewReader(data))
+	if err != nil || len(regions) != 0 {
+		t.Fatalf("expected no changed regions, got %v (%v)", regions, err)
+	}
+
+	// An overwritten range shows up as one region covering it
+	changed := bytes.Clone(data)
+	fillLCG(changed[300*kiB:340*kiB], 11)
+	regions, err = CompareSignature(s, bytes.NewReader(changed))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(regions) != 1 {
+		t.Fatalf("expected 1 changed region, got %v", regions)
+	}
+	r := regions[0]
+	if r.Offset > 300*kiB || r.Offset+r.Length < 340*kiB || r.Length > 40*kiB+2*int64(DefaultParams.MaxSize) {
+		t.Fatalf("expected region around %d+%d, got %d+%d", 300*kiB, 40*kiB, r.Offset, r.Length)
+	}
+
+	if _, err := ReadSignature(bytes.NewReader(data[:100])); !errors.Is(err, ErrSignatureFormat) {
+		t.Fatalf("expected ErrSignatureFormat, got %v", err)
+	}
+
+	// Params that cannot be chunked with are rejected
+	empty := func(p [3]uint64) []byte {
+		sig := append([]byte("FCDS"), 1)
+		for _, v := range p {
+			sig = binary.AppendUvarint(sig, v)
+		}
+		return append(sig, 0, 0)
+	}
+	if _, err := ReadSignature(bytes.NewReader(empty([3]uint64{2048, 8192, 32768}))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, p := range [][3]uint64{{0, 8192, 32768}, {4, 8, 16}, {8192, 2048, 32768}, {2048, 8192, 1 << 40}} {
+		if _, err := ReadSignature(bytes.NewReader(empty(p))); !errors.Is(err, ErrSignatureFormat) {
+			t.Fatalf("%v: expected ErrSignatureFormat, got %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package fastcdc
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ErrSize is wrapped by ParseSize errors
+var ErrSize = errors.New("fastcdc: invalid size")
+
+// sizeUnits are the suffixes accepted by ParseSize. Single letters are
+// binary, as chunk sizes usually are.
+var sizeUnits = map[string]int{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kib": 1 << 10,
+	"kb":  1e3,
+	"m":   1 << 20,
+	"mib": 1 << 20,
+	"mb":  1e6,
+	"g":   1 << 30,
+	"gib": 1 << 30,
+	"gb":  1e9,
+}
+
+// ParseSize parses a byte count such as "8KiB", "1M" or "65536". Units
+// are case insensitive. K, M and G are binary like KiB, MiB and GiB,
+// while KB, MB and GB are decimal.
+func ParseSize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if i < 0 {
+		i = len(s)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(s[i:]))]
+	n, err := strconv.Atoi(s[:i])
+	if !ok || err != nil || n > math.MaxInt/unit {
+		return 0, fmt.Errorf("%w %q", ErrSize, s)
+	}
+	return n * unit, nil
+}
+
+// ParseParams returns the params with the sizes given as strings for
+// ParseSize, such as ParseParams("2KiB", "8KiB", "32KiB"), after checking
+// them with Params.Validate
+func ParseParams(minSize, avgSize, maxSize string) (Params, error) {
+	var p Params
+	for _, f := range []struct {
+		s   string
+		dst *int
+	}{{minSize, &p.MinSize}, {avgSize, &p.AvgSize}, {maxSize, &p.MaxSize}} {
+		n, err := ParseSize(f.s)
+		if err != nil {
+			return Params{}, err
+		}
+		*f.dst = n
+	}
+	if err := p.Validate(); err != nil {
+		return Params{}, err
+	}
+	return p, nil
+}
+
+// FormatSize formats n in the largest binary unit that divides it, so
+// that ParseSize returns n again
+func FormatSize(n int) string {
+	for _, u := range []struct {
+		suffix string
+		size   int
+	}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if n != 0 && n%u.size == 0 {
+			return strconv.Itoa(n/u.size) + u.suffix
+		}
+	}
+	return strconv.Itoa(n)
+}
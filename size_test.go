@@ -0,0 +1,65 @@
+package fastcdc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int{
+		"0":       0,
+		"65536":   65536,
+		"512B":    512,
+		"8KiB":    8 * kiB,
+		"8k":      8 * kiB,
+		"8 KB":    8000,
+		"1MiB":    miB,
+		" 4m ":    4 * miB,
+		"2GB":     2e9,
+		"1gib":    1 << 30,
+		"1000kib": 1000 * kiB,
+	} {
+		got, err := ParseSize(s)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", s, err)
+		} else if got != want {
+			t.Errorf("%q: expected %d, got %d", s, want, got)
+		}
+	}
+	for _, s := range []string{"", "KiB", "-1", "1.5M", "8 KiBs", "1T", "99999999999999999999G"} {
+		if _, err := ParseSize(s); !errors.Is(err, ErrSize) {
+			t.Errorf("%q: expected ErrSize, got %v", s, err)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int]string{
+		0:          "0",
+		1000:       "1000",
+		8 * kiB:    "8KiB",
+		1536 * kiB: "1536KiB",
+		4 * miB:    "4MiB",
+		3 << 30:    "3GiB",
+	} {
+		if got := FormatSize(n); got != want {
+			t.Errorf("%d: expected %q, got %q", n, want, got)
+		}
+		if got, err := ParseSize(want); err != nil || got != n {
+			t.Errorf("%q: expected %d, got %d, %v", want, n, got, err)
+		}
+	}
+}
+
+func TestParseParams(t *testing.T) {
+	p, err := ParseParams("2KiB", "8k", "32768")
+	if err != nil || p != DefaultParams {
+		t.Errorf("expected %v, got %v (%v)", DefaultParams, p, err)
+	}
+	if _, err := ParseParams("2KiB", "8 KiBs", "32KiB"); !errors.Is(err, ErrSize) {
+		t.Errorf("expected ErrSize, got %v", err)
+	}
+	if _, err := ParseParams("2KiB", "8", "32KiB"); !errors.Is(err, ErrInvalidParams) {
+		t.Errorf("expected ErrInvalidParams, got %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package fastcdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// ErrNotSQLite is returned when input does not start with a SQLite header
+var ErrNotSQLite = errors.New("fastcdc: not a SQLite database")
+
+const sqliteHeaderSize = 100
+
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// SQLitePageSize returns the page size of a SQLite database from its header
+func SQLitePageSize(header []byte) (int, error) {
+	if len(header) < sqliteHeaderSize || !bytes.HasPrefix(header, sqliteMagic) {
+		return 0, ErrNotSQLite
+	}
+
+	size := int(binary.BigEndian.Uint16(header[16:18]))
+	if size == 1 {
+		size = 64 * kiB
+	}
+	if size < 512 || size&(size-1) != 0 {
+		return 0, ErrNotSQLite
+	}
+	return size, nil
+}
+
+// NewSQLiteChunker reads the header of a SQLite database from reader and
+// returns a Chunker that only cuts at page boundaries. Chunk sizes are
+// scaled from the page size so that each chunk spans several pages.
+func NewSQLiteChunker(reader io.Reader, opts ...Option) (*Chunker, error) {
+	header := make([]byte, sqliteHeaderSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrNotSQLite
+		}
+		return nil, err
+	}
+
+	page, err := SQLitePageSize(header)
+	if err != nil {
+		return nil, err
+	}
+
+	reader = io.MultiReader(bytes.NewReader(header), reader)
+	opts = append([]Option{WithAlignment(page)}, opts...)
+	return NewChunkerWithParams(reader, 2*page, 8*page, 32*page, opts...), nil
+}
@@ -0,0 +1,74 @@
+package fastcdc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// sqliteData returns a fake database with the given page size
+func sqliteData(pageSize uint16, size int) []byte {
+	data := make([]byte, size)
+	fillLCG(data, 42)
+	copy(data, sqliteMagic)
+	binary.BigEndian.PutUint16(data[16:18], pageSize)
+	return data
+}
+
+func TestSQLiteChunker(t *testing.T) {
+	data := sqliteData(4096, 4*miB+100)
+
+	chunker, err := NewSQLiteChunker(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	total := 0
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("error getting next chunk: %v", err)
+		}
+		if !bytes.Equal(chunk.Data, data[chunk.Offset:chunk.Offset+len(chunk.Data)]) {
+			t.Fatalf("chunk data mismatch at offset %d", chunk.Offset)
+		}
+
+		end := chunk.Offset + len(chunk.Data)
+		if end != len(data) && end%4096 != 0 {
+			t.Errorf("chunk ending at %d is not page aligned", end)
+		}
+		total += len(chunk.Data)
+	}
+	if total != len(data) {
+		t.Errorf("expected %d bytes in chunks, got %d", len(data), total)
+	}
+}
+
+func TestSQLitePageSize(t *testing.T) {
+	tests := []struct {
+		header []byte
+		size   int
+		err    error
+	}{
+		{sqliteData(4096, 100), 4096, nil},
+		{sqliteData(1, 100), 64 * kiB, nil},
+		{sqliteData(1000, 100), 0, ErrNotSQLite},
+		{sqliteData(4096, 50), 0, ErrNotSQLite},
+		{make([]byte, 100), 0, ErrNotSQLite},
+	}
+
+	for i, test := range tests {
+		size, err := SQLitePageSize(test.header)
+		if size != test.size || err != test.err {
+			t.Errorf("test %d: expected %d, %v, got %d, %v", i, test.size, test.err, size, err)
+		}
+	}
+
+	if _, err := NewSQLiteChunker(bytes.NewReader([]byte("short"))); err != ErrNotSQLite {
+		t.Errorf("expected ErrNotSQLite for short input, got %v", err)
+	}
+}
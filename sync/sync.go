@@ -0,0 +1,218 @@
+// Package sync transfers a file to a receiver that has an older version of
+// it, sending only the chunks the receiver lacks. The receiver sends the
+// signature of its version, and the sender replies with a delta of copy
+// instructions for chunks found in the signature and literal data for the
+// rest.
+package sync
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+)
+
+// magic starts a delta, and changes with incompatible versions
+var magic = [4]byte{'F', 'C', 'D', '1'}
+
+// Delta instructions
+const (
+	opEnd     = 0 // Varint size and SHA-256 of the result
+	opCopy    = 1 // Varint offset and length in the old version
+	opLiteral = 2 // Varint length and the data
+)
+
+var (
+	// ErrFormat is wrapped by errors for malformed deltas and signatures
+	ErrFormat = errors.New("sync: invalid delta format")
+
+	// ErrMismatch is returned when the result does not match the size and
+	// hash of the sender's version, such as when the old version changed
+	// after its signature was taken
+	ErrMismatch = errors.New("sync: result does not match")
+)
+
+// Stats counts the bytes a delta copies from the old version and sends as
+// literals
+type Stats struct {
+	Copied  int64
+	Literal int64
+}
+
+// Send chunks r with the params of sig and writes a delta to w that turns
+// the version sig was taken of into the contents of r. Copies of adjacent
+// chunks are merged.
+func Send(w io.Writer, sig *fastcdc.Signature, r io.Reader, opts ...fastcdc.Option) (Stats, error) {
+	var stats Stats
+	if err := sig.Params.Validate(); err != nil {
+		return stats, fmt.Errorf("%w: %w", ErrFormat, err)
+	}
+	bw := bufio.NewWriter(w)
+	var buf []byte
+	put := func(op byte, values ...uint64) error {
+		buf = append(buf[:0], op)
+		for _, v := range values {
+			buf = binary.AppendUvarint(buf, v)
+		}
+		_, err := bw.Write(buf)
+		return err
+	}
+
+	bw.Write(magic[:])
+	m := fastcdc.NewMatcher(sig, opts...)
+	c := fastcdc.NewChunker(r, append([]fastcdc.Option{fastcdc.WithParams(sig.Params)}, opts...)...)
+	h := sha256.New()
+	var size int64
+	var copyOffset, copyLength int64 // Pending copy
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return stats, err
+		}
+		h.Write(chunk.Data)
+		size += int64(chunk.Length)
+
+		e, ok := m.Match(chunk.Data)
+		if copyLength > 0 && (!ok || copyOffset+copyLength != e.Offset) {
+			if err := put(opCopy, uint64(copyOffset), uint64(copyLength)); err != nil {
+				return stats, err
+			}
+			copyLength = 0
+		}
+		if ok {
+			if copyLength == 0 {
+				copyOffset = e.Offset
+			}
+			copyLength += int64(e.Length)
+			stats.Copied += int64(e.Length)
+			continue
+		}
+
+		if err := put(opLiteral, uint64(chunk.Length)); err != nil {
+			return stats, err
+		}
+		if _, err := bw.Write(chunk.Data); err != nil {
+			return stats, err
+		}
+		stats.Literal += int64(chunk.Length)
+	}
+
+	if copyLength > 0 {
+		if err := put(opCopy, uint64(copyOffset), uint64(copyLength)); err != nil {
+			return stats, err
+		}
+	}
+	if err := put(opEnd, uint64(size)); err != nil {
+		return stats, err
+	}
+	bw.Write(h.Sum(nil))
+	return stats, bw.Flush()
+}
+
+// Apply writes the result of applying delta to the old version to w, and
+// checks it against the size and hash of the sender's version. The result
+// is written before it is checked, so it must be discarded on error.
+func Apply(w io.Writer, old io.ReaderAt, delta io.Reader) error {
+	br, ok := delta.(byteReader)
+	if !ok {
+		br = bufio.NewReader(delta)
+	}
+
+	var m [4]byte
+	if _, err := io.ReadFull(br, m[:]); err != nil || m != magic {
+		return fmt.Errorf("%w: bad magic", ErrFormat)
+	}
+
+	h := sha256.New()
+	hw := io.MultiWriter(w, h)
+	var size int64
+	for {
+		op, err := br.ReadByte()
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+
+		switch op {
+		case opCopy:
+			offset, err1 := binary.ReadUvarint(br)
+			length, err2 := binary.ReadUvarint(br)
+			if err := errors.Join(err1, err2); err != nil {
+				return fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			n, err := io.Copy(hw, io.NewSectionReader(old, int64(offset), int64(length)))
+			size += n
+			if err != nil {
+				return err
+			}
+			if n != int64(length) {
+				return fmt.Errorf("%w: copy of %d bytes at %d past the end of the old version", ErrMismatch, length, offset)
+			}
+
+		case opLiteral:
+			length, err := binary.ReadUvarint(br)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			n, err := io.CopyN(hw, br, int64(length))
+			size += n
+			if err == io.EOF {
+				return fmt.Errorf("%w: truncated literal", ErrFormat)
+			}
+			if err != nil {
+				return err
+			}
+
+		case opEnd:
+			expectedSize, err := binary.ReadUvarint(br)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			var sum [sha256.Size]byte
+			if _, err := io.ReadFull(br, sum[:]); err != nil {
+				return fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			if size != int64(expectedSize) || [sha256.Size]byte(h.Sum(nil)) != sum {
+				return ErrMismatch
+			}
+			return nil
+
+		default:
+			return fmt.Errorf("%w: unknown instruction %d", ErrFormat, op)
+		}
+	}
+}
+
+// Receive sends the signature of the first size bytes of old over conn,
+// and writes the sender's version to w. Opts set the chunk params.
+func Receive(conn io.ReadWriter, w io.Writer, old io.ReaderAt, size int64, opts ...fastcdc.Option) error {
+	if err := fastcdc.WriteSignature(conn, io.NewSectionReader(old, 0, size), opts...); err != nil {
+		return err
+	}
+	return Apply(w, old, conn)
+}
+
+// Serve reads the signature of a receiver from conn and replies with a
+// delta to the contents of r. Signatures with params that cannot be
+// chunked with are rejected with ErrFormat.
+func Serve(conn io.ReadWriter, r io.Reader, opts ...fastcdc.Option) (Stats, error) {
+	sig, err := fastcdc.ReadSignature(conn)
+	if errors.Is(err, fastcdc.ErrSignatureFormat) {
+		return Stats{}, fmt.Errorf("%w: %w", ErrFormat, err)
+	}
+	if err != nil {
+		return Stats{}, err
+	}
+	return Send(conn, sig, r, opts...)
+}
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
@@ -0,0 +1,107 @@
+package sync
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	fastcdc "github.com/jokkebk/go-fastcdc"
+	"github.com/jokkebk/go-fastcdc/datagen"
+)
+
+func TestSendApply(t *testing.T) {
+	old := make([]byte, 1<<20)
+	datagen.FillLCG(old, 1)
+
+	// Insert, overwrite and delete some data
+	insert := make([]byte, 1000)
+	datagen.FillLCG(insert, 2)
+	data := append(bytes.Clone(old[:100000]), insert...)
+	data = append(data, old[100000:500000]...)
+	data = append(data, old[600000:]...)
+	datagen.FillLCG(data[800000:810000], 3)
+
+	sig, err := fastcdc.NewSignature(bytes.NewReader(old))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var delta bytes.Buffer
+	stats, err := Send(&delta, sig, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stats.Copied+stats.Literal != int64(len(data)) {
+		t.Fatalf("expected stats to cover %d bytes, got %+v", len(data), stats)
+	}
+	if stats.Literal > 100000 || delta.Len() > 100000 {
+		t.Fatalf("expected a small delta, got %d bytes with %+v", delta.Len(), stats)
+	}
+
+	var result bytes.Buffer
+	if err := Apply(&result, bytes.NewReader(old), bytes.NewReader(delta.Bytes())); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(result.Bytes(), data) {
+		t.Fatalf("result does not match")
+	}
+
+	// A changed old version is detected
+	changed := bytes.Clone(old)
+	changed[300000]++
+	err = Apply(&result, bytes.NewReader(changed), bytes.NewReader(delta.Bytes()))
+	if !errors.Is(err, ErrMismatch) {
+		t.Fatalf("expected ErrMismatch, got %v", err)
+	}
+
+	err = Apply(&result, bytes.NewReader(old), bytes.NewReader(delta.Bytes()[:delta.Len()-40]))
+	if !errors.Is(err, ErrFormat) {
+		t.Fatalf("expected ErrFormat for truncated delta, got %v", err)
+	}
+}
+
+func TestReceiveServe(t *testing.T) {
+	old := make([]byte, 256<<10)
+	datagen.FillLCG(old, 4)
+	data := append(bytes.Clone(old[:50000]), old[60000:]...)
+
+	p := fastcdc.Params{MinSize: 1 << 10, AvgSize: 4 << 10, MaxSize: 16 << 10}
+	client, server := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		_, err := Serve(server, bytes.NewReader(data))
+		server.Close()
+		done <- err
+	}()
+
+	var result bytes.Buffer
+	if err := Receive(client, &result, bytes.NewReader(old), int64(len(old)), fastcdc.WithParams(p)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected server error: %v", err)
+	}
+	if !bytes.Equal(result.Bytes(), data) {
+		t.Fatalf("result does not match")
+	}
+}
+
+func TestServeBadParams(t *testing.T) {
+	sig := &fastcdc.Signature{Params: fastcdc.Params{MinSize: 4, AvgSize: 8, MaxSize: 16}}
+	if _, err := Send(io.Discard, sig, bytes.NewReader(nil)); !errors.Is(err, ErrFormat) {
+		t.Fatalf("expected ErrFormat, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := sig.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	conn := struct {
+		io.Reader
+		io.Writer
+	}{&buf, io.Discard}
+	if _, err := Serve(conn, bytes.NewReader(nil)); !errors.Is(err, ErrFormat) {
+		t.Fatalf("expected ErrFormat, got %v", err)
+	}
+}
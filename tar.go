@@ -0,0 +1,90 @@
+package fastcdc
+
+import (
+	"bytes"
+	"strconv"
+)
+
+const tarBlock = 512
+
+// WithTarBoundaries forces cuts before each header of a tar stream and
+// where the contents of each member start, so member contents dedupe
+// regardless of their names, timestamps and what precedes them in the
+// archive. Headers end up as chunks of their own. Members larger than the
+// maximum size are chunked as usual. If the stream stops looking like a
+// tar archive, chunking continues without forced cuts.
+func WithTarBoundaries() Option {
+	return func(c *Chunker) {
+		c.policies = append(c.policies, &tarPolicy{header: true})
+	}
+}
+
+// tarPolicy follows the member headers of a tar stream. It keeps state, so
+// each Chunker gets its own, which starts over when the stream does.
+type tarPolicy struct {
+	next   int  // Stream offset of the next boundary
+	header bool // Whether a header starts at next, or member contents
+	size   int  // Padded size of the contents starting at next
+	lost   bool // Whether the stream stopped looking like a tar archive
+}
+
+// Hint returns the position of the next header or member contents in data
+func (p *tarPolicy) Hint(offset int, data []byte) int {
+	if offset == 0 {
+		*p = tarPolicy{header: true}
+	}
+	for !p.lost {
+		switch i := p.next - offset; {
+		case i > 0:
+			return i
+		case i < 0:
+			p.lost = true
+		case !p.header:
+			p.next += p.size
+			p.header = true
+		case len(data) < tarBlock:
+			return 0
+		default:
+			size, ok := tarSize(data[:tarBlock])
+			p.lost = !ok
+			p.next += tarBlock
+			p.size = (size + tarBlock - 1) &^ (tarBlock - 1)
+			p.header = size == 0
+		}
+	}
+	return 0
+}
+
+func (p *tarPolicy) Adjust(offset int, data []byte, cutPoint int) int {
+	return cutPoint
+}
+
+// tarSize returns the size of the member contents following a header
+// block, or false if the block is not a valid header, such as the zero
+// blocks ending an archive
+func tarSize(hdr []byte) (int, bool) {
+	// The checksum is the sum of the header bytes with its own field as
+	// spaces
+	sum := 8 * int(' ')
+	for i, b := range hdr {
+		if i < 148 || i >= 156 {
+			sum += int(b)
+		}
+	}
+	chksum, err := strconv.ParseInt(string(bytes.Trim(hdr[148:156], " \x00")), 8, 64)
+	if err != nil || int(chksum) != sum {
+		return 0, false
+	}
+
+	// Sizes are octal, or big-endian base-256 when the top bit is set
+	field := hdr[124:136]
+	if field[0]&0x80 != 0 {
+		var size uint64
+		for _, b := range field[1:] {
+			size = size<<8 | uint64(b)
+		}
+		return int(size), size < 1<<62
+	}
+	size, err := strconv.ParseInt(string(bytes.Trim(field, " \x00")), 8, 64)
+	return int(size), err == nil && size >= 0
+}
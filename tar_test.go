@@ -0,0 +1,97 @@
+package fastcdc
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+// tarArchive returns an archive of files with the given contents, and the
+// offsets of its headers and of nonempty member contents
+func tarArchive(t *testing.T, files [][]byte, mtime time.Time) ([]byte, []int) {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	var boundaries []int
+	for i, data := range files {
+		tw.Flush()
+		boundaries = append(boundaries, buf.Len())
+		hdr := &tar.Header{Name: fmt.Sprintf("file%d", i), Mode: 0644, Size: int64(len(data)), ModTime: mtime}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		tw.Flush()
+		if len(data) > 0 {
+			boundaries = append(boundaries, buf.Len())
+		}
+		if _, err := tw.Write(data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), boundaries
+}
+
+func TestTarBoundaries(t *testing.T) {
+	random := make([]byte, 1*miB)
+	fillLCG(random, 15)
+	var files [][]byte
+	for i := range 40 {
+		size := (i * 7919) % (80 * kiB)
+		files = append(files, random[i*kiB:i*kiB+size])
+	}
+	data, boundaries := tarArchive(t, files, time.Unix(1e9, 0))
+
+	cuts := map[int]bool{}
+	chunker := NewChunker(bytes.NewReader(data), WithTarBoundaries())
+	for range 2 {
+		for {
+			chunk, err := chunker.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			cuts[chunk.Offset] = true
+		}
+		for _, b := range boundaries {
+			if !cuts[b] {
+				t.Fatalf("expected cut at tar boundary %d", b)
+			}
+		}
+
+		// The policy starts over with the stream
+		chunker.Reset(bytes.NewReader(data))
+		clear(cuts)
+	}
+
+	// Member contents dedupe across archives with other metadata, unlike
+	// without the policy
+	other, _ := tarArchive(t, append([][]byte{random[:100]}, files...), time.Unix(2e9, 0))
+	withTar := sharedBytes(t, data, other, WithTarBoundaries())
+	without := sharedBytes(t, data, other)
+	if withTar < len(other)*9/10 || withTar <= without {
+		t.Fatalf("expected most of %d bytes shared, got %d (%d without policy)", len(other), withTar, without)
+	}
+}
+
+// sharedBytes returns the number of bytes in chunks of b also found in a
+func sharedBytes(t *testing.T, a, b []byte, opts ...Option) int {
+	seen := map[string]bool{}
+	for _, c := range chunkAll(t, a, opts...) {
+		seen[string(c.Data)] = true
+	}
+	shared := 0
+	for _, c := range chunkAll(t, b, opts...) {
+		if seen[string(c.Data)] {
+			shared += c.Length
+		}
+	}
+	return shared
+}
@@ -0,0 +1,323 @@
+//go:build linux && !tinygo
+
+package fastcdc
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// Minimal io_uring support for sequential file reads with registered
+// buffers, using raw system calls to avoid external dependencies
+
+const (
+	sysIOURingSetup    = 425
+	sysIOURingEnter    = 426
+	sysIOURingRegister = 427
+
+	ioringOffSQRing = 0
+	ioringOffCQRing = 0x8000000
+	ioringOffSQEs   = 0x10000000
+
+	ioringOpReadFixed     = 4
+	ioringEnterGetEvents  = 1
+	ioringRegisterBuffers = 0
+	uringEntries          = 8
+	uringBuffers          = 4
+	uringBufferSize       = 128 * kiB
+	sqeSize, cqeSize      = 64, 16
+)
+
+var errRingBusy = errors.New("fastcdc: io_uring has reads in flight")
+
+// uringParams mirrors struct io_uring_params
+type uringParams struct {
+	sqEntries, cqEntries, flags, sqThreadCPU, sqThreadIdle, features, wqFd uint32
+	resv                                                                   [3]uint32
+	sqOff                                                                  sqRingOffsets
+	cqOff                                                                  cqRingOffsets
+}
+
+type sqRingOffsets struct {
+	head, tail, ringMask, ringEntries, flags, dropped, array, resv1 uint32
+	userAddr                                                        uint64
+}
+
+type cqRingOffsets struct {
+	head, tail, ringMask, ringEntries, overflow, cqes, flags, resv1 uint32
+	userAddr                                                        uint64
+}
+
+// ring is an io_uring instance with registered read buffers. It is reused
+// for many files but reads only one file at a time.
+type ring struct {
+	fd             int
+	sq, cq, sqes   []byte
+	p              uringParams
+	bufs           [][]byte
+	sqTail, cqHead *uint32
+	inFlight       int
+	maxRead        int // Limits the length of reads to test short reads, 0 for none
+}
+
+func newRing() (*ring, error) {
+	r := &ring{}
+	fd, _, errno := syscall.Syscall(sysIOURingSetup, uringEntries, uintptr(unsafe.Pointer(&r.p)), 0)
+	if errno != 0 {
+		return nil, errno
+	}
+	r.fd = int(fd)
+
+	var err error
+	mmap := func(offset int64, size uint32) []byte {
+		if err != nil {
+			return nil
+		}
+		var mem []byte
+		mem, err = syscall.Mmap(r.fd, offset, int(size), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+		return mem
+	}
+	r.sq = mmap(ioringOffSQRing, r.p.sqOff.array+r.p.sqEntries*4)
+	r.cq = mmap(ioringOffCQRing, r.p.cqOff.cqes+r.p.cqEntries*cqeSize)
+	r.sqes = mmap(ioringOffSQEs, r.p.sqEntries*sqeSize)
+
+	// Buffers live outside the Go heap so the kernel can keep them pinned
+	iovecs := make([]syscall.Iovec, uringBuffers)
+	for i := range iovecs {
+		if err != nil {
+			break
+		}
+		var buf []byte
+		if buf, err = syscall.Mmap(-1, 0, uringBufferSize, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_ANON|syscall.MAP_PRIVATE); err == nil {
+			r.bufs = append(r.bufs, buf)
+			iovecs[i].Base = &buf[0]
+			iovecs[i].SetLen(len(buf))
+		}
+	}
+	if err == nil {
+		_, _, errno = syscall.Syscall6(sysIOURingRegister, fd, ioringRegisterBuffers, uintptr(unsafe.Pointer(&iovecs[0])), uringBuffers, 0, 0)
+		if errno != 0 {
+			err = errno
+		}
+	}
+	if err != nil {
+		r.close()
+		return nil, err
+	}
+
+	r.sqTail = (*uint32)(unsafe.Pointer(&r.sq[r.p.sqOff.tail]))
+	r.cqHead = (*uint32)(unsafe.Pointer(&r.cq[r.p.cqOff.head]))
+	return r, nil
+}
+
+func (r *ring) close() {
+	for _, mem := range append([][]byte{r.sq, r.cq, r.sqes}, r.bufs...) {
+		if mem != nil {
+			syscall.Munmap(mem)
+		}
+	}
+	syscall.Close(r.fd)
+}
+
+// submit queues a read into buffer i from start on, from f at offset
+func (r *ring) submit(f *os.File, i, start int, offset int64) error {
+	tail := atomic.LoadUint32(r.sqTail)
+	mask := *(*uint32)(unsafe.Pointer(&r.sq[r.p.sqOff.ringMask]))
+	idx := tail & mask
+
+	length := len(r.bufs[i]) - start
+	if r.maxRead > 0 {
+		length = min(length, r.maxRead)
+	}
+	sqe := r.sqes[idx*sqeSize : (idx+1)*sqeSize]
+	clear(sqe)
+	sqe[0] = ioringOpReadFixed
+	*(*int32)(unsafe.Pointer(&sqe[4])) = int32(f.Fd())
+	*(*uint64)(unsafe.Pointer(&sqe[8])) = uint64(offset)
+	*(*uint64)(unsafe.Pointer(&sqe[16])) = uint64(uintptr(unsafe.Pointer(&r.bufs[i][start])))
+	*(*uint32)(unsafe.Pointer(&sqe[24])) = uint32(length)
+	*(*uint64)(unsafe.Pointer(&sqe[32])) = uint64(i)
+	*(*uint16)(unsafe.Pointer(&sqe[40])) = uint16(i)
+
+	array := (*uint32)(unsafe.Pointer(&r.sq[r.p.sqOff.array+idx*4]))
+	*array = idx
+	atomic.StoreUint32(r.sqTail, tail+1)
+
+	r.inFlight++
+	return r.enter(1, 0)
+}
+
+// wait blocks until at least one read completes and calls fn for each
+// completed buffer with the result of the read
+func (r *ring) wait(fn func(i int, res int32)) error {
+	if err := r.enter(0, 1); err != nil {
+		return err
+	}
+
+	head := atomic.LoadUint32(r.cqHead)
+	tail := atomic.LoadUint32((*uint32)(unsafe.Pointer(&r.cq[r.p.cqOff.tail])))
+	mask := *(*uint32)(unsafe.Pointer(&r.cq[r.p.cqOff.ringMask]))
+	for ; head != tail; head++ {
+		cqe := r.cq[r.p.cqOff.cqes+(head&mask)*cqeSize:]
+		i := *(*uint64)(unsafe.Pointer(&cqe[0]))
+		res := *(*int32)(unsafe.Pointer(&cqe[8]))
+		r.inFlight--
+		fn(int(i), res)
+	}
+	atomic.StoreUint32(r.cqHead, head)
+	return nil
+}
+
+func (r *ring) enter(submit, minComplete uint32) error {
+	for {
+		_, _, errno := syscall.Syscall6(sysIOURingEnter, uintptr(r.fd), uintptr(submit), uintptr(minComplete), ioringEnterGetEvents, 0, 0)
+		if errno == syscall.EINTR {
+			continue
+		}
+		if errno != 0 {
+			return errno
+		}
+		return nil
+	}
+}
+
+// reader returns a reader for f which keeps all buffers of the ring busy
+// reading ahead. It must be closed before the ring is used again.
+func (r *ring) reader(f *os.File) io.ReadCloser {
+	n := len(r.bufs)
+	u := &uringReader{r: r, f: f, size: -1, offsets: make([]int64, n), filled: make([]int, n), pending: make([]bool, n), short: make([]bool, n)}
+	if r.inFlight > 0 {
+		u.err = errRingBusy
+		return u
+	}
+	if fi, err := f.Stat(); err == nil && fi.Mode().IsRegular() {
+		u.size = fi.Size()
+	}
+	for i := range r.bufs {
+		u.order = append(u.order, i)
+		if u.err = u.submit(i); u.err != nil {
+			break
+		}
+	}
+	return u
+}
+
+type uringReader struct {
+	r       *ring
+	f       *os.File
+	size    int64   // Size of the file when opened, -1 if unknown
+	next    int64   // File offset of next buffer to submit
+	order   []int   // Buffers in flight, in file order
+	offsets []int64 // File offset of each buffer
+	filled  []int   // Bytes read into each buffer so far
+	pending []bool  // Whether each buffer has a read in flight
+	short   []bool  // Whether a read into each buffer hit the end of the file
+	cur     []byte  // Unread data of the first buffer in order
+	eof     bool    // Whether the end of the file has been reached
+	err     error
+}
+
+// submit starts reading the next part of the file into buffer i
+func (u *uringReader) submit(i int) error {
+	u.offsets[i], u.filled[i], u.pending[i], u.short[i] = u.next, 0, true, false
+	u.next += int64(len(u.r.bufs[i]))
+	return u.r.submit(u.f, i, 0, u.offsets[i])
+}
+
+// complete reports whether buffer i holds all it will get. A short read,
+// such as one interrupted by a signal or from a network file system, does
+// not end the file, so the rest of it is read again.
+func (u *uringReader) complete(i int) bool {
+	end := u.offsets[i] + int64(u.filled[i])
+	return u.filled[i] == len(u.r.bufs[i]) || u.short[i] || u.size >= 0 && end >= u.size
+}
+
+func (u *uringReader) Read(p []byte) (int, error) {
+	for len(u.cur) == 0 {
+		if u.err != nil {
+			return 0, u.err
+		}
+		if err := u.advance(); err != nil {
+			u.err = err
+		}
+	}
+
+	n := copy(p, u.cur)
+	u.cur = u.cur[n:]
+	return n, nil
+}
+
+// advance resubmits the consumed buffer and waits for the next one
+func (u *uringReader) advance() error {
+	if u.cur != nil {
+		i := u.order[0]
+		u.order = u.order[1:]
+		u.cur = nil
+		if !u.eof {
+			if err := u.submit(i); err != nil {
+				return err
+			}
+			u.order = append(u.order, i)
+		}
+	}
+	if len(u.order) == 0 {
+		return io.EOF
+	}
+
+	i := u.order[0]
+	for u.pending[i] || !u.complete(i) {
+		if !u.pending[i] {
+			u.pending[i] = true
+			if err := u.r.submit(u.f, i, u.filled[i], u.offsets[i]+int64(u.filled[i])); err != nil {
+				return err
+			}
+		}
+		if err := u.r.wait(func(i int, res int32) {
+			u.pending[i] = false
+			switch {
+			case res < 0:
+				u.err = syscall.Errno(-res)
+			case res == 0:
+				u.short[i] = true
+			default:
+				u.filled[i] += int(res)
+			}
+		}); err != nil {
+			return err
+		}
+		if u.err != nil {
+			return u.err
+		}
+	}
+
+	// Stop at the first buffer ending the file, leaving any later ones
+	// for Close to drain
+	res := u.filled[i]
+	if u.size >= 0 {
+		res = int(min(int64(res), max(u.size-u.offsets[i], 0)))
+	}
+	if res < len(u.r.bufs[i]) {
+		u.eof = true
+		u.order = u.order[:1]
+	}
+	if res == 0 {
+		u.order = nil
+		return io.EOF
+	}
+	u.cur = u.r.bufs[i][:res:res]
+	return nil
+}
+
+// Close waits for reads still in flight, so the ring can be reused
+func (u *uringReader) Close() error {
+	for u.r.inFlight > 0 {
+		if err := u.r.wait(func(int, int32) {}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+//go:build linux && !tinygo
+
+package fastcdc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestURingReader(t *testing.T) {
+	r, err := newRing()
+	if err != nil {
+		t.Skipf("io_uring unavailable: %v", err)
+	}
+	defer r.close()
+
+	dir := t.TempDir()
+	// Sizes around buffer and read-ahead boundaries, reusing the same ring
+	sizes := []int{0, 1, uringBufferSize, uringBufferSize + 1,
+		uringBuffers * uringBufferSize, 3*uringBuffers*uringBufferSize + 12345}
+	for _, size := range sizes {
+		data := make([]byte, size)
+		fillLCG(data, uint32(size))
+		path := filepath.Join(dir, fmt.Sprintf("file%d", size))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		checkURingReader(t, r, path, data)
+	}
+
+	// Short reads are continued rather than taken as the end of the file
+	r.maxRead = 1000
+	for _, size := range sizes {
+		data := make([]byte, size)
+		fillLCG(data, uint32(size))
+		checkURingReader(t, r, filepath.Join(dir, fmt.Sprintf("file%d", size)), data)
+	}
+	r.maxRead = 0
+
+	// Closing early drains reads in flight so the ring can be reused
+	f, err := os.Open(filepath.Join(dir, fmt.Sprintf("file%d", uringBuffers*uringBufferSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rc := r.reader(f)
+	if _, err := rc.Read(make([]byte, 10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("unexpected close error: %v", err)
+	}
+	if r.inFlight != 0 {
+		t.Errorf("expected no reads in flight, got %d", r.inFlight)
+	}
+}
+
+// checkURingReader reads the file at path through r and compares it to data
+func checkURingReader(t *testing.T, r *ring, path string, data []byte) {
+	t.Helper()
+	size := len(data)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc := r.reader(f)
+	got, err := io.ReadAll(io.LimitReader(rc, int64(size)+1))
+	if err != nil {
+		t.Fatalf("size %d: unexpected error: %v", size, err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("size %d: unexpected close error: %v", size, err)
+	}
+	f.Close()
+	if !bytes.Equal(got, data) {
+		t.Fatalf("size %d: expected file contents, got %d bytes", size, len(got))
+	}
+}
+
+func TestChunkFilesIOUring(t *testing.T) {
+	if r, err := newRing(); err != nil {
+		t.Skipf("io_uring unavailable: %v", err)
+	} else {
+		r.close()
+	}
+
+	dir := t.TempDir()
+	p := Params{MinSize: 1 * kiB, AvgSize: 4 * kiB, MaxSize: 16 * kiB}
+
+	files := map[string][]byte{}
+	var paths []string
+	for i := 0; i < 8; i++ {
+		data := make([]byte, i*300*kiB)
+		fillLCG(data, uint32(i))
+		path := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		files[path] = data
+		paths = append(paths, path)
+	}
+
+	// Read-ahead must be done with each file before its reader is closed,
+	// also when chunking stops early, so each file is read twice and the
+	// first read is stopped after one chunk
+	var twice []string
+	for _, path := range paths {
+		twice = append(twice, path, path)
+	}
+	stop := errors.New("stop")
+	for name, opts := range map[string][]Option{
+		"plain":      {WithParams(p), WithIOUring()},
+		"read-ahead": {WithParams(p), WithIOUring(), WithReadAhead(8)},
+	} {
+		started := map[string]bool{}
+		offsets := map[string][]int{}
+		err := ChunkFiles(twice, 1, func(path string, c Chunk) error {
+			if !bytes.Equal(c.Data, files[path][c.Offset:c.Offset+c.Length]) {
+				return fmt.Errorf("chunk data mismatch at offset %d", c.Offset)
+			}
+			if !started[path] {
+				started[path] = true
+				return stop
+			}
+			offsets[path] = append(offsets[path], c.Offset)
+			return nil
+		}, opts...)
+		if err != nil && !errors.Is(err, stop) {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+
+		for path, data := range files {
+			expected := Rechunk(data, p)
+			if len(offsets[path]) != len(expected) {
+				t.Fatalf("%s, %s: expected %d chunks, got %d", name, path, len(expected), len(offsets[path]))
+			}
+			for i, offset := range offsets[path] {
+				if offset != expected[i].Offset {
+					t.Errorf("%s, %s: expected offset %d at index %d, got %d", name, path, expected[i].Offset, i, offset)
+				}
+			}
+		}
+	}
+}
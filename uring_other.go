@@ -0,0 +1,22 @@
+//go:build !linux || tinygo
+
+package fastcdc
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// ring is a placeholder for io_uring, which is only available on Linux
+type ring struct{}
+
+func newRing() (*ring, error) {
+	return nil, errors.New("fastcdc: io_uring is not supported")
+}
+
+func (r *ring) close() {}
+
+func (r *ring) reader(f *os.File) io.ReadCloser {
+	return io.NopCloser(f)
+}
@@ -0,0 +1,110 @@
+package fastcdc
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidChunks is wrapped by all errors returned from Validate
+var ErrInvalidChunks = errors.New("fastcdc: invalid chunks")
+
+// ErrInvalidParams is wrapped by all errors returned from Params.Validate
+var ErrInvalidParams = errors.New("fastcdc: invalid params")
+
+// Bounds checked by Params.Validate. Smaller averages leave too few mask
+// bits, and the max size bounds the buffer of a Chunker.
+const (
+	MinAvgSize   = 64
+	MaxChunkSize = 64 * miB
+)
+
+// Validate checks that p can be used to chunk: sizes must be positive and
+// ordered, the average at least MinAvgSize and the maximum at most
+// MaxChunkSize. Params from untrusted sources, such as signatures from a
+// peer or configuration, should be validated before use.
+func (p Params) Validate() error {
+	switch {
+	case p.MinSize <= 0:
+		return fmt.Errorf("%w: min size %d not positive", ErrInvalidParams, p.MinSize)
+	case p.MinSize > p.AvgSize || p.AvgSize > p.MaxSize:
+		return fmt.Errorf("%w: sizes %d/%d/%d not ordered", ErrInvalidParams, p.MinSize, p.AvgSize, p.MaxSize)
+	case p.AvgSize < MinAvgSize:
+		return fmt.Errorf("%w: avg size %d below %d", ErrInvalidParams, p.AvgSize, MinAvgSize)
+	case p.MaxSize > MaxChunkSize:
+		return fmt.Errorf("%w: max size %d above %d", ErrInvalidParams, p.MaxSize, MaxChunkSize)
+	}
+	return nil
+}
+
+// Validate checks that chunks are contiguous from offset 0, cover exactly
+// totalSize bytes and respect the size bounds of p. If all chunks carry
+// data, it is also re-scanned to verify that every boundary is a genuine
+// gear hash cut point, so data must not have been invalidated by a later
+// call to Next. Chunks produced with boundary policies do not pass the
+// re-scan. Opts should be those of the Chunker, so the re-scan uses the
+// same scan and gear table, as set by WithTwoByteScan and WithGearTable.
+func Validate(chunks []Chunk, totalSize int64, p Params, opts ...Option) error {
+	var c Chunker
+	for _, opt := range opts {
+		opt(&c)
+	}
+	c.setParams(p)
+
+	rescan := true
+	for _, chunk := range chunks {
+		rescan = rescan && chunk.Data != nil
+	}
+
+	var scan []byte
+	offset := 0
+	for i, chunk := range chunks {
+		n := chunk.Length
+		if chunk.Data != nil {
+			n = len(chunk.Data)
+		}
+		last := i == len(chunks)-1
+
+		switch {
+		case chunk.Offset != offset:
+			return invalidChunk(i, chunk, "expected offset %d", offset)
+		case n == 0:
+			return invalidChunk(i, chunk, "empty chunk")
+		case n > p.MaxSize:
+			return invalidChunk(i, chunk, "size %d above maximum", n)
+		case n < p.MinSize && !last:
+			return invalidChunk(i, chunk, "size %d below minimum", n)
+		}
+
+		// The cut point scan includes the first byte of the next chunk
+		if rescan && last {
+			if cut, _ := c.findCutPoint(chunk.Data); cut != n {
+				return invalidChunk(i, chunk, "earlier cut point in final chunk")
+			}
+		} else if rescan && n == p.MaxSize {
+			if cut, _ := c.findCutPoint(chunk.Data); cut != n {
+				return invalidChunk(i, chunk, "not a gear cut point")
+			}
+		} else if rescan {
+			if len(chunks[i+1].Data) == 0 {
+				return invalidChunk(i+1, chunks[i+1], "empty chunk")
+			}
+			// The two-byte scan tests cut points a pair of bytes at a time
+			next := chunks[i+1].Data
+			scan = append(append(scan[:0], chunk.Data...), next[:min(2, len(next))]...)
+			if cut, _ := c.findCutPoint(scan); cut != n {
+				return invalidChunk(i, chunk, "not a gear cut point")
+			}
+		}
+
+		offset += n
+	}
+
+	if int64(offset) != totalSize {
+		return fmt.Errorf("%w: chunks cover %d bytes, expected %d", ErrInvalidChunks, offset, totalSize)
+	}
+	return nil
+}
+
+func invalidChunk(i int, chunk Chunk, format string, args ...any) error {
+	return fmt.Errorf("%w: chunk %d at offset %d: %s", ErrInvalidChunks, i, chunk.Offset, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,92 @@
+package fastcdc
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 42)
+
+	p := DefaultParams
+	chunks := Rechunk(data, p)
+	if err := Validate(chunks, int64(len(data)), p); err != nil {
+		t.Fatalf("expected valid chunks, got %v", err)
+	}
+
+	// Chunks at the maximum size are valid
+	capped := Params{MinSize: 2 * kiB, AvgSize: 8 * kiB, MaxSize: 9 * kiB}
+	if err := Validate(Rechunk(data, capped), int64(len(data)), capped); err != nil {
+		t.Fatalf("expected valid capped chunks, got %v", err)
+	}
+
+	// Move the boundary between the first two chunks
+	shifted := append([]Chunk(nil), chunks...)
+	shifted[0].Data = data[:len(chunks[0].Data)+1]
+	shifted[1].Offset++
+	shifted[1].Data = shifted[1].Data[1:]
+
+	// Merge the first two chunks
+	merged := append([]Chunk{{Offset: 0, Data: data[:chunks[2].Offset]}}, chunks[2:]...)
+
+	tests := map[string]struct {
+		chunks []Chunk
+		size   int64
+	}{
+		"gap":       {append(chunks[:1:1], chunks[2:]...), int64(len(data))},
+		"total":     {chunks, int64(len(data)) + 1},
+		"shifted":   {shifted, int64(len(data))},
+		"merged":    {merged, int64(len(data))},
+		"undersize": {[]Chunk{{Offset: 0, Data: data[:100]}, {Offset: 100, Data: data[100:200]}}, 200},
+		"final":     {[]Chunk{{Offset: 0, Data: bytes.Clone(data[:chunks[1].Offset+100])}}, int64(chunks[1].Offset + 100)},
+		"empty":     {[]Chunk{chunks[0], {Offset: chunks[1].Offset, Data: []byte{}}}, int64(chunks[1].Offset)},
+	}
+
+	for name, test := range tests {
+		err := Validate(test.chunks, test.size, p)
+		if !errors.Is(err, ErrInvalidChunks) {
+			t.Errorf("%s: expected ErrInvalidChunks, got %v", name, err)
+		}
+	}
+}
+
+func TestValidateOptions(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 43)
+
+	table := GearTable(7)
+	for name, opts := range map[string][]Option{
+		"two-byte": {WithTwoByteScan()},
+		"gear":     {WithGearTable(table)},
+	} {
+		chunks := chunkAll(t, data, opts...)
+		if err := Validate(chunks, int64(len(data)), DefaultParams, opts...); err != nil {
+			t.Errorf("%s: expected valid chunks, got %v", name, err)
+		}
+		if name == "gear" && Validate(chunks, int64(len(data)), DefaultParams) == nil {
+			t.Errorf("%s: expected chunks invalid with the default gear table", name)
+		}
+	}
+}
+
+func TestParamsValidate(t *testing.T) {
+	for _, p := range []Params{DefaultParams, {MinSize: 16 * kiB, AvgSize: 16 * kiB, MaxSize: 16 * kiB}, {MinSize: 1, AvgSize: MinAvgSize, MaxSize: MaxChunkSize}} {
+		if err := p.Validate(); err != nil {
+			t.Errorf("%v: unexpected error: %v", p, err)
+		}
+	}
+	for _, p := range []Params{
+		{},
+		{MinSize: 0, AvgSize: 8 * kiB, MaxSize: 32 * kiB},
+		{MinSize: 4 * kiB, AvgSize: 2 * kiB, MaxSize: 32 * kiB},
+		{MinSize: 2 * kiB, AvgSize: 8 * kiB, MaxSize: 4 * kiB},
+		{MinSize: 4, AvgSize: 8, MaxSize: 16},
+		{MinSize: 2 * kiB, AvgSize: 8 * kiB, MaxSize: MaxChunkSize + 1},
+	} {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidParams) {
+			t.Errorf("%v: expected ErrInvalidParams, got %v", p, err)
+		}
+	}
+}
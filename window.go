@@ -0,0 +1,14 @@
+package fastcdc
+
+import "io"
+
+// NewChunkerAt returns a Chunker for the length bytes of r at offset, with
+// chunk offsets relative to the start of r. This chunks a window of a
+// larger object, such as the region around an edit found by comparing
+// signatures. Cut points are found as if the object started at offset, so
+// they match the chunks of the whole object once they resynchronize.
+func NewChunkerAt(r io.ReaderAt, offset, length int64, p Params, opts ...Option) *Chunker {
+	c := NewChunkerWithParams(io.NewSectionReader(r, offset, length), p.MinSize, p.AvgSize, p.MaxSize, opts...)
+	c.base = int(offset)
+	return c
+}
@@ -0,0 +1,39 @@
+package fastcdc
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestNewChunkerAt(t *testing.T) {
+	data := make([]byte, 1*miB)
+	fillLCG(data, 1)
+	whole := chunkAll(t, data)
+
+	// A window starting at a chunk boundary gives the same chunks
+	start := whole[10]
+	end := whole[40]
+	c := NewChunkerAt(bytes.NewReader(data), int64(start.Offset), int64(end.Offset-start.Offset), DefaultParams)
+	for i := 10; i < 40; i++ {
+		chunk, err := c.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if chunk.Offset != whole[i].Offset || chunk.Length != whole[i].Length {
+			t.Fatalf("chunk %d: expected %d+%d, got %d+%d", i, whole[i].Offset, whole[i].Length, chunk.Offset, chunk.Length)
+		}
+		if !bytes.Equal(chunk.Data, data[chunk.Offset:chunk.Offset+chunk.Length]) {
+			t.Fatalf("chunk %d: data does not match", i)
+		}
+	}
+	if _, err := c.Next(); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	// Reset starts offsets from zero again
+	c.Reset(bytes.NewReader(data[:100]))
+	if chunk, _ := c.Next(); chunk.Offset != 0 {
+		t.Fatalf("expected offset 0 after Reset, got %d", chunk.Offset)
+	}
+}